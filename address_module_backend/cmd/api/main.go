@@ -1,10 +1,17 @@
 package main
 
 import (
+	"context"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"address_module/internal/handlers"
+	"address_module/internal/notifier"
+	"address_module/internal/scheduler"
+	"address_module/internal/token"
 	"address_module/internal/tools"
 
 	"github.com/go-chi/chi"
@@ -60,6 +67,10 @@ func main() {
 	}
 	defer pgDB.Close()
 
+	if err := pgDB.SetupDatabase(); err != nil {
+		log.Fatalf("Failed to initialize device database schema: %v", err)
+	}
+
 	// Run CRUD test for device management DB
 	if err := tools.RunPostgresDeviceCRUDTests(pgDB); err != nil {
 		log.Errorf("Postgres device CRUD tests failed: %v", err)
@@ -67,6 +78,49 @@ func main() {
 		log.Info("Postgres device CRUD tests passed ✅")
 	}
 
+	// Stop background jobs and the HTTP server on Ctrl+C / docker stop
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Notification settings, shared by the outbox dispatcher and the handlers
+	notifyCfg := notifier.ConfigFromEnv()
+
+	// Background jobs
+	sched := scheduler.New(scheduler.SystemClock, db)
+	schedCfg := scheduler.ConfigFromEnv()
+	if schedCfg.Enabled {
+		sched.Register(&scheduler.SLAEscalationJob{
+			Store: db,
+			Lead:  schedCfg.EscalationLead,
+			Every: schedCfg.EscalationInterval,
+		})
+		sched.Register(&scheduler.WarrantyExpiryJob{
+			Devices:    pgDB,
+			Tickets:    db,
+			NoticeDays: schedCfg.WarrantyNoticeDays,
+			Every:      schedCfg.WarrantyInterval,
+		})
+		sched.Register(&scheduler.TokenCleanupJob{
+			Store: db,
+			Every: schedCfg.TokenCleanupEvery,
+		})
+		if schedCfg.RetentionDays > 0 {
+			sched.Register(&scheduler.SoftDeletePurgeJob{
+				Stores:        []scheduler.PurgeStore{db, pgDB},
+				RetentionDays: schedCfg.RetentionDays,
+				Every:         schedCfg.PurgeInterval,
+			})
+		}
+		if notifyCfg.Enabled() {
+			sched.Register(notifier.NewDispatcher(db, notifier.NewSMTPSender(notifyCfg), notifyCfg))
+		} else {
+			log.Warn("SMTP_HOST not set, notification emails stay in the outbox")
+		}
+		sched.Start(ctx)
+	} else {
+		log.Info("Scheduler disabled via SCHEDULER_ENABLED")
+	}
+
 	// Create a new router
 	r := chi.NewRouter()
 
@@ -80,8 +134,14 @@ func main() {
 	})
 	r.Use(corsMiddleware.Handler)
 
-	// Register API Routes
-	handlers.Handler(r)
+	// Token signing keys
+	keys, err := token.KeyringFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	// Register API Routes on the shared connection pools
+	handlers.NewServer(db, pgDB, keys, notifyCfg).Handler(r)
 
 	// Log server start
 	log.Info("Starting GO API backend service on port 8000...")
@@ -94,7 +154,22 @@ func main() {
 
 	log.Info("Server is ready to handle requests")
 
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("Server failed to start: %v", err)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed to start: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Info("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Errorf("HTTP server shutdown failed: %v", err)
 	}
+
+	// Let running jobs finish before the deferred Close calls drop the DB connections
+	sched.Wait()
+	log.Info("Shutdown complete")
 }
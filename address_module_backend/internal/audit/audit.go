@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"address_module/internal/model"
+	"encoding/json"
+	"reflect"
+)
+
+// Store is what the audit log needs from the database. It only ever appends.
+type Store interface {
+	InsertAuditEntry(entry model.AuditEntry) (int64, error)
+}
+
+// redacted fields are recorded as changed without their values. The audit log
+// is append-only, so credentials written to it could never be removed again.
+var redacted = map[string]bool{
+	"hashed_password": true,
+	"password":        true,
+	"password_link":   true,
+	"token":           true,
+	"token_hash":      true,
+	"refresh_token":   true,
+	"access_jti":      true,
+	"secret":          true,
+}
+
+// Record appends an entry for a change made by actor (nil for anonymous
+// requests). before and after are the entity as returned by the API; pass nil
+// for the side that does not exist (create, delete).
+func Record(store Store, actor *int64, action, entityType string, entityID int64, before, after interface{}) error {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return err
+	}
+	_, err = store.InsertAuditEntry(model.AuditEntry{
+		UserID:     actor,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+	})
+	return err
+}
+
+// Diff compares the JSON form of before and after and returns
+// {"before": {...}, "after": {...}} with the fields that differ. A nil side
+// stays null and the other side is recorded in full.
+func Diff(before, after interface{}) (json.RawMessage, error) {
+	b, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	if b != nil && a != nil {
+		for key, old := range b {
+			if cur, ok := a[key]; ok && reflect.DeepEqual(old, cur) {
+				delete(b, key)
+				delete(a, key)
+			}
+		}
+	}
+	redact(b)
+	redact(a)
+
+	return json.Marshal(map[string]map[string]interface{}{"before": b, "after": a})
+}
+
+// toFields turns a value into its JSON object fields; nil for nil values
+func toFields(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func redact(fields map[string]interface{}) {
+	for key := range fields {
+		if redacted[key] {
+			fields[key] = "[redacted]"
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"address_module/internal/audit"
+	"address_module/internal/model"
+	"address_module/internal/notifier"
+	"address_module/internal/tools"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AccountStore is what the password reset and email verification flows need
+// from the database. Emails are queued through the same store.
+type AccountStore interface {
+	notifier.Store
+	audit.Store
+	GetUserByEmail(email string) (*model.User, error)
+	CreateUserToken(userID int64, purpose, tokenHash string, expiresAt time.Time) error
+	ResetPassword(tokenHash, hashedPassword string, now time.Time) (int64, error)
+	VerifyEmail(tokenHash string, now time.Time) (int64, error)
+	RevokeUserSessions(userID int64, now time.Time) (int64, error)
+}
+
+// AccountConfig holds the password reset and email verification settings read from the environment
+type AccountConfig struct {
+	RequireVerification bool          // REQUIRE_EMAIL_VERIFICATION, default true
+	ResetTTL            time.Duration // PASSWORD_RESET_TTL, default 1h
+	VerificationTTL     time.Duration // EMAIL_VERIFICATION_TTL, default 48h
+	AppURL              string        // APP_BASE_URL, where the links in the emails point to
+}
+
+// AccountConfigFromEnv reads the account settings, falling back to defaults for unset or invalid values
+func AccountConfigFromEnv() AccountConfig {
+	cfg := AccountConfig{
+		RequireVerification: true,
+		ResetTTL:            time.Hour,
+		VerificationTTL:     48 * time.Hour,
+		AppURL:              strings.TrimRight(os.Getenv("APP_BASE_URL"), "/"),
+	}
+	if cfg.AppURL == "" {
+		cfg.AppURL = "http://localhost:7080"
+	}
+	if v := os.Getenv("REQUIRE_EMAIL_VERIFICATION"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.RequireVerification = b
+		} else {
+			log.Warnf("Invalid REQUIRE_EMAIL_VERIFICATION=%q, using %v", v, cfg.RequireVerification)
+		}
+	}
+	for name, dst := range map[string]*time.Duration{
+		"PASSWORD_RESET_TTL":     &cfg.ResetTTL,
+		"EMAIL_VERIFICATION_TTL": &cfg.VerificationTTL,
+	} {
+		v := os.Getenv(name)
+		if v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Warnf("Invalid %s=%q, using %s", name, v, *dst)
+			continue
+		}
+		*dst = d
+	}
+	return cfg
+}
+
+// accountLink builds the frontend link carrying a token
+func (s *Server) accountLink(path, token string) string {
+	return s.Account.AppURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// sendUserToken stores a fresh token for the user and queues the email carrying it
+func (s *Server) sendUserToken(user model.User, purpose string) error {
+	ttl, path := s.Account.ResetTTL, "/reset-password"
+	if purpose == tools.UserTokenEmailVerification {
+		ttl, path = s.Account.VerificationTTL, "/verify-email"
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return err
+	}
+	expiresAt := time.Now().Add(ttl)
+	if err := s.Accounts.CreateUserToken(user.ID, purpose, hashToken(token), expiresAt); err != nil {
+		return err
+	}
+
+	link := s.accountLink(path, token)
+	s.notify(s.Accounts, func(n *notifier.Notifier) error {
+		if purpose == tools.UserTokenEmailVerification {
+			return n.EmailVerification(user, link, expiresAt)
+		}
+		return n.PasswordReset(user, link, expiresAt)
+	})
+	return nil
+}
+
+// writeTokenSent answers forgot/resend requests the same way whether or not the
+// address is registered, so the endpoints cannot be used to probe for accounts
+func writeTokenSent(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "If the address belongs to an account, an email has been sent",
+	})
+}
+
+// ForgotPasswordHandler emails a single-use password reset link
+func (s *Server) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req model.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Email) == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.Accounts.GetUserByEmail(strings.TrimSpace(strings.ToLower(req.Email)))
+	if err == nil {
+		if err := s.sendUserToken(*user, tools.UserTokenPasswordReset); err != nil {
+			log.Errorf("Failed to create password reset token for user %d: %v", user.ID, err)
+		}
+	}
+	writeTokenSent(w)
+}
+
+// ResetPasswordHandler sets a new password using a reset token. The token is
+// used up together with the password change, the email counts as verified and
+// all existing sessions are revoked.
+func (s *Server) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req model.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" || req.Password == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Error("Failed to hash password: ", err)
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	userID, err := s.Accounts.ResetPassword(hashToken(req.Token), string(hashed), now)
+	if errors.Is(err, tools.ErrUserTokenInvalid) {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_token", "Reset link is invalid or has expired")
+		return
+	}
+	if err != nil {
+		log.Errorf("Password reset failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not reset password")
+		return
+	}
+
+	if _, err := s.Accounts.RevokeUserSessions(userID, now); err != nil {
+		log.Errorf("Failed to revoke sessions after password reset of user %d: %v", userID, err)
+	}
+	recordAudit(s.Accounts, r, model.AuditActionUpdate, model.AuditEntityUser, userID, nil, map[string]bool{"password_reset": true})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Password reset successfully",
+	})
+}
+
+// VerifyEmailHandler confirms a user's email address with the token from the verification email
+func (s *Server) VerifyEmailHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req model.VerifyEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	_, err := s.Accounts.VerifyEmail(hashToken(req.Token), time.Now())
+	if errors.Is(err, tools.ErrUserTokenInvalid) {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_token", "Verification link is invalid or has expired")
+		return
+	}
+	if err != nil {
+		log.Errorf("Verifying email failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not verify email")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Email verified successfully",
+	})
+}
+
+// ResendVerificationHandler sends a new verification link to an unverified account
+func (s *Server) ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req model.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Email) == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	user, err := s.Accounts.GetUserByEmail(strings.TrimSpace(strings.ToLower(req.Email)))
+	if err == nil && user.EmailVerifiedAt == nil {
+		if err := s.sendUserToken(*user, tools.UserTokenEmailVerification); err != nil {
+			log.Errorf("Failed to create verification token for user %d: %v", user.ID, err)
+		}
+	}
+	writeTokenSent(w)
+}
@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"address_module/internal/model"
+	"address_module/internal/notifier"
+	"address_module/internal/tools"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeUserToken is a row of the user_tokens table
+type fakeUserToken struct {
+	userID    int64
+	purpose   string
+	hash      string
+	expiresAt time.Time
+	usedAt    *time.Time
+}
+
+// fakeAccountStore keeps users, tokens and the email outbox in memory and
+// follows the same rules as the MySQL store: a token works once, only before
+// it expires, and a new token replaces older ones of the same purpose.
+type fakeAccountStore struct {
+	users   map[int64]*model.User
+	tokens  []*fakeUserToken
+	outbox  []model.OutboxEmail
+	revoked map[int64]bool
+}
+
+func newFakeAccountStore(users ...model.User) *fakeAccountStore {
+	f := &fakeAccountStore{users: map[int64]*model.User{}, revoked: map[int64]bool{}}
+	for i := range users {
+		f.users[users[i].ID] = &users[i]
+	}
+	return f
+}
+
+func (f *fakeAccountStore) GetUserByEmail(email string) (*model.User, error) {
+	for _, u := range f.users {
+		if u.Email == email {
+			copy := *u
+			return &copy, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (f *fakeAccountStore) CreateUserToken(userID int64, purpose, tokenHash string, expiresAt time.Time) error {
+	now := time.Now()
+	for _, t := range f.tokens {
+		if t.userID == userID && t.purpose == purpose && t.usedAt == nil {
+			t.usedAt = &now
+		}
+	}
+	f.tokens = append(f.tokens, &fakeUserToken{userID: userID, purpose: purpose, hash: tokenHash, expiresAt: expiresAt})
+	return nil
+}
+
+func (f *fakeAccountStore) consume(purpose, tokenHash string, now time.Time) (*model.User, error) {
+	for _, t := range f.tokens {
+		if t.hash != tokenHash || t.purpose != purpose {
+			continue
+		}
+		if t.usedAt != nil || !now.Before(t.expiresAt) {
+			return nil, tools.ErrUserTokenInvalid
+		}
+		t.usedAt = &now
+		return f.users[t.userID], nil
+	}
+	return nil, tools.ErrUserTokenInvalid
+}
+
+func (f *fakeAccountStore) ResetPassword(tokenHash, hashedPassword string, now time.Time) (int64, error) {
+	user, err := f.consume(tools.UserTokenPasswordReset, tokenHash, now)
+	if err != nil {
+		return 0, err
+	}
+	user.HashedPassword = hashedPassword
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+	}
+	return user.ID, nil
+}
+
+func (f *fakeAccountStore) VerifyEmail(tokenHash string, now time.Time) (int64, error) {
+	user, err := f.consume(tools.UserTokenEmailVerification, tokenHash, now)
+	if err != nil {
+		return 0, err
+	}
+	if user.EmailVerifiedAt == nil {
+		user.EmailVerifiedAt = &now
+	}
+	return user.ID, nil
+}
+
+func (f *fakeAccountStore) RevokeUserSessions(userID int64, now time.Time) (int64, error) {
+	f.revoked[userID] = true
+	return 1, nil
+}
+
+func (f *fakeAccountStore) InsertAuditEntry(entry model.AuditEntry) (int64, error) {
+	return 1, nil
+}
+
+func (f *fakeAccountStore) GetTicketRecipients(ticketID int64) (*model.TicketRecipients, error) {
+	return nil, errors.New("no tickets in account tests")
+}
+
+func (f *fakeAccountStore) EnqueueEmail(email model.OutboxEmail) (int64, error) {
+	email.ID = int64(len(f.outbox) + 1)
+	email.Status = model.EmailStatusPending
+	f.outbox = append(f.outbox, email)
+	return email.ID, nil
+}
+
+func (f *fakeAccountStore) GetDueEmails(now time.Time, limit int) ([]model.OutboxEmail, error) {
+	var due []model.OutboxEmail
+	for _, e := range f.outbox {
+		if e.Status == model.EmailStatusPending && len(due) < limit {
+			due = append(due, e)
+		}
+	}
+	return due, nil
+}
+
+func (f *fakeAccountStore) MarkEmailSent(id int64, at time.Time) error {
+	f.outbox[id-1].Status = model.EmailStatusSent
+	return nil
+}
+
+func (f *fakeAccountStore) MarkEmailFailed(id int64, sendErr error, retryAt time.Time) error {
+	f.outbox[id-1].Status = model.EmailStatusFailed
+	return nil
+}
+
+// expireTokens moves the expiry of every token into the past
+func (f *fakeAccountStore) expireTokens() {
+	for _, t := range f.tokens {
+		t.expiresAt = time.Now().Add(-time.Minute)
+	}
+}
+
+type sentMail struct {
+	to, subject, body string
+}
+
+// fakeSender stands in for the SMTP server and keeps every delivered email
+type fakeSender struct {
+	sent []sentMail
+}
+
+func (s *fakeSender) Send(to, subject, body string) error {
+	s.sent = append(s.sent, sentMail{to, subject, body})
+	return nil
+}
+
+var linkToken = regexp.MustCompile(`https?://\S+\?token=(\S+)`)
+
+// deliver runs the outbox dispatcher once and returns the token from the
+// single email it sent to the given address
+func deliver(t *testing.T, store *fakeAccountStore, to string) string {
+	t.Helper()
+	sender := &fakeSender{}
+	d := &notifier.Dispatcher{Store: store, Sender: sender, MaxAttempts: 1, BatchSize: 10}
+	if err := d.Run(context.Background(), time.Now()); err != nil {
+		t.Fatalf("dispatching outbox: %v", err)
+	}
+	if len(sender.sent) != 1 || sender.sent[0].to != to {
+		t.Fatalf("expected one email to %s, got %+v", to, sender.sent)
+	}
+	m := linkToken.FindStringSubmatch(sender.sent[0].body)
+	if m == nil {
+		t.Fatalf("no link in email body:\n%s", sender.sent[0].body)
+	}
+	token, err := url.QueryUnescape(m[1])
+	if err != nil {
+		t.Fatalf("bad token in link %q: %v", m[0], err)
+	}
+	return token
+}
+
+func post(handler http.HandlerFunc, body interface{}) *httptest.ResponseRecorder {
+	raw, _ := json.Marshal(body)
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(raw))))
+	return rec
+}
+
+func newAccountServer(store *fakeAccountStore) *Server {
+	return &Server{
+		Accounts: store,
+		Account: AccountConfig{
+			RequireVerification: true,
+			ResetTTL:            time.Hour,
+			VerificationTTL:     time.Hour,
+			AppURL:              "http://app.test",
+		},
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	store := newFakeAccountStore(model.User{ID: 7, Username: "alice", Email: "alice@example.com"})
+	s := newAccountServer(store)
+
+	if rec := post(s.ForgotPasswordHandler, model.ForgotPasswordRequest{Email: "Alice@Example.com"}); rec.Code != http.StatusAccepted {
+		t.Fatalf("forgot: got %d", rec.Code)
+	}
+	token := deliver(t, store, "alice@example.com")
+
+	rec := post(s.ResetPasswordHandler, model.ResetPasswordRequest{Token: token, Password: "n3w-secret"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("reset: got %d: %s", rec.Code, rec.Body)
+	}
+	user := store.users[7]
+	if bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte("n3w-secret")) != nil {
+		t.Error("password was not changed")
+	}
+	if user.EmailVerifiedAt == nil {
+		t.Error("reset should mark the email verified")
+	}
+	if !store.revoked[7] {
+		t.Error("reset should revoke existing sessions")
+	}
+
+	// The link works exactly once
+	rec = post(s.ResetPasswordHandler, model.ResetPasswordRequest{Token: token, Password: "other"})
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_token") {
+		t.Fatalf("second reset: got %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestPasswordResetExpired(t *testing.T) {
+	store := newFakeAccountStore(model.User{ID: 7, Username: "alice", Email: "alice@example.com", HashedPassword: "old"})
+	s := newAccountServer(store)
+
+	post(s.ForgotPasswordHandler, model.ForgotPasswordRequest{Email: "alice@example.com"})
+	token := deliver(t, store, "alice@example.com")
+	store.expireTokens()
+
+	rec := post(s.ResetPasswordHandler, model.ResetPasswordRequest{Token: token, Password: "n3w-secret"})
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_token") {
+		t.Fatalf("expired reset: got %d: %s", rec.Code, rec.Body)
+	}
+	if store.users[7].HashedPassword != "old" {
+		t.Error("expired token changed the password")
+	}
+}
+
+func TestForgotPasswordUnknownAddress(t *testing.T) {
+	store := newFakeAccountStore()
+	s := newAccountServer(store)
+
+	if rec := post(s.ForgotPasswordHandler, model.ForgotPasswordRequest{Email: "nobody@example.com"}); rec.Code != http.StatusAccepted {
+		t.Fatalf("forgot: got %d", rec.Code)
+	}
+	if len(store.outbox) != 0 {
+		t.Fatalf("expected no email, got %d", len(store.outbox))
+	}
+}
+
+func TestEmailVerification(t *testing.T) {
+	store := newFakeAccountStore(model.User{ID: 3, Username: "bob", Email: "bob@example.com"})
+	s := newAccountServer(store)
+
+	if rec := post(s.ResendVerificationHandler, model.ForgotPasswordRequest{Email: "bob@example.com"}); rec.Code != http.StatusAccepted {
+		t.Fatalf("resend: got %d", rec.Code)
+	}
+	token := deliver(t, store, "bob@example.com")
+
+	if rec := post(s.VerifyEmailHandler, model.VerifyEmailRequest{Token: token}); rec.Code != http.StatusOK {
+		t.Fatalf("verify: got %d: %s", rec.Code, rec.Body)
+	}
+	if store.users[3].EmailVerifiedAt == nil {
+		t.Fatal("email not marked verified")
+	}
+
+	rec := post(s.VerifyEmailHandler, model.VerifyEmailRequest{Token: token})
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_token") {
+		t.Fatalf("second verify: got %d: %s", rec.Code, rec.Body)
+	}
+
+	// Verified accounts get no further verification emails
+	post(s.ResendVerificationHandler, model.ForgotPasswordRequest{Email: "bob@example.com"})
+	if len(store.outbox) != 1 {
+		t.Fatalf("expected no new email after verification, outbox has %d", len(store.outbox))
+	}
+}
+
+func TestEmailVerificationExpired(t *testing.T) {
+	store := newFakeAccountStore(model.User{ID: 3, Username: "bob", Email: "bob@example.com"})
+	s := newAccountServer(store)
+
+	post(s.ResendVerificationHandler, model.ForgotPasswordRequest{Email: "bob@example.com"})
+	token := deliver(t, store, "bob@example.com")
+	store.expireTokens()
+
+	rec := post(s.VerifyEmailHandler, model.VerifyEmailRequest{Token: token})
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid_token") {
+		t.Fatalf("expired verify: got %d: %s", rec.Code, rec.Body)
+	}
+	if store.users[3].EmailVerifiedAt != nil {
+		t.Error("expired token verified the email")
+	}
+
+	// A fresh link replaces the expired one
+	post(s.ResendVerificationHandler, model.ForgotPasswordRequest{Email: "bob@example.com"})
+	token = deliver(t, store, "bob@example.com")
+	if rec := post(s.VerifyEmailHandler, model.VerifyEmailRequest{Token: token}); rec.Code != http.StatusOK {
+		t.Fatalf("verify with new link: got %d: %s", rec.Code, rec.Body)
+	}
+}
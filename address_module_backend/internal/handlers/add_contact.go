@@ -7,7 +7,6 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
-	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -16,7 +15,7 @@ var ErrMissingContactFields = errors.New("missing required contact fields")
 var ErrMissingFirmID = errors.New("missing required firm ID")
 
 // AddContact handles contact registration requests
-func AddContact(w http.ResponseWriter, r *http.Request) {
+func (s *Server) AddContact(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
 		return
@@ -39,15 +38,6 @@ func AddContact(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connect to MySQL database
-	db, err := tools.NewDatabase(5, 3*time.Second)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-		api.InternalErrorHandler(w)
-		return
-	}
-	defer db.Close()
-
 	// Convert api.ContactParams to tools.ContactParams
 	contact := tools.ContactParams{
 		Anrede:     params.Anrede,
@@ -68,7 +58,7 @@ func AddContact(w http.ResponseWriter, r *http.Request) {
 	// Insert contact with relationships
 	if len(params.Firms) > 0 {
 		// Insert contact and create relationships with multiple firms
-		contactID, err = db.InsertContactWithFirms(contact, params.Firms)
+		contactID, err = s.DB.InsertContactWithFirms(contact, params.Firms)
 		if err != nil {
 			log.Error("Failed to insert contact data with firm relationships: ", err)
 			api.InternalErrorHandler(w)
@@ -78,7 +68,7 @@ func AddContact(w http.ResponseWriter, r *http.Request) {
 	} else {
 		//Insert a contact without any firm relationships
 		log.Info("Inserting contact without firm relationships")
-		contactID, err = db.InsertContact(contact)
+		contactID, err = s.DB.InsertContact(contact)
 		if err != nil {
 			log.Error("Failed to insert contact data: ", err)
 			api.InternalErrorHandler(w)
@@ -102,6 +92,7 @@ func AddContact(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.WithFields(logFields).Info("Contact successfully registered in database")
+	s.recordAudit(r, model.AuditActionCreate, model.AuditEntityContact, contactID, nil, params)
 
 	// Respond with success
 	w.Header().Set("Content-Type", "application/json")
@@ -7,7 +7,6 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
-	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -15,7 +14,7 @@ import (
 var ErrMissingFields = errors.New("missing required fields")
 
 // AddFirm handles firm registration requests
-func AddFirm(w http.ResponseWriter, r *http.Request) {
+func (s *Server) AddFirm(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
 		return
@@ -38,15 +37,6 @@ func AddFirm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connect to MySQL database
-	db, err := tools.NewDatabase(5, 3*time.Second)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-		api.InternalErrorHandler(w)
-		return
-	}
-	defer db.Close()
-
 	// Convert `api.FirmParams` to `tools.FirmParams`
 	firm := tools.FirmParams{
 		Anrede:    params.Anrede,
@@ -73,7 +63,7 @@ func AddFirm(w http.ResponseWriter, r *http.Request) {
 	// Check if contact IDs were provided to create relationships
 	if len(params.ContactIDs) > 0 {
 		// Insert firm data and create relationships with multiple contacts
-		firmID, insertErr = db.InsertFirmWithContacts(firm, params.ContactIDs)
+		firmID, insertErr = s.DB.InsertFirmWithContacts(firm, params.ContactIDs)
 		if insertErr != nil {
 			log.Error("Failed to insert firm data with contact relationships: ", insertErr)
 			api.InternalErrorHandler(w)
@@ -82,7 +72,7 @@ func AddFirm(w http.ResponseWriter, r *http.Request) {
 		log.WithField("contact_ids", params.ContactIDs).Info("Firm associated with contacts")
 	} else if params.ContactID > 0 {
 		// For backward compatibility: Insert firm data and create relationship with a single contact
-		firmID, insertErr = db.InsertFirmWithContact(firm, params.ContactID)
+		firmID, insertErr = s.DB.InsertFirmWithContact(firm, params.ContactID)
 		if insertErr != nil {
 			log.Error("Failed to insert firm data with contact relationship: ", insertErr)
 			api.InternalErrorHandler(w)
@@ -91,7 +81,7 @@ func AddFirm(w http.ResponseWriter, r *http.Request) {
 		log.WithField("contact_id", params.ContactID).Info("Firm associated with contact")
 	} else {
 		// Insert firm data only
-		firmID, insertErr = db.InsertFirm(firm)
+		firmID, insertErr = s.DB.InsertFirm(firm)
 		if insertErr != nil {
 			log.Error("Failed to insert firm data: ", insertErr)
 			api.InternalErrorHandler(w)
@@ -128,6 +118,7 @@ func AddFirm(w http.ResponseWriter, r *http.Request) {
 	}
 
 	log.WithFields(logFields).Info("Firm successfully registered in database")
+	s.recordAudit(r, model.AuditActionCreate, model.AuditEntityFirm, firmID, nil, params)
 
 	// Respond with success
 	w.Header().Set("Content-Type", "application/json")
@@ -1,90 +1,181 @@
 package handlers
 
 import (
-	"address_module/internal/middleware"
-
 	"github.com/go-chi/chi"
 	chimiddle "github.com/go-chi/chi/middleware"
 )
 
-func Handler(r *chi.Mux) {
+// Handler registers all API routes on r
+func (s *Server) Handler(r *chi.Mux) {
+	authorization := s.Auth.Authorization
+	requirePermission := s.Permissions.Require
+
 	// Global middleware
 	r.Use(chimiddle.StripSlashes)
 
+	// Public keys for verifying our tokens offline
+	r.Get("/.well-known/jwks.json", s.JWKS)
+
 	// Login & Register
 	r.Route("/auth", func(router chi.Router) {
-		router.Post("/login", LoginHandler) // points to middleware package now
-		router.Post("/register", RegisterHandler)
+		router.Post("/login", s.LoginHandler) // points to middleware package now
+		router.Post("/register", s.RegisterHandler)
+		router.Post("/refresh", s.RefreshHandler)
+		router.Post("/forgot", s.ForgotPasswordHandler)
+		router.Post("/reset", s.ResetPasswordHandler)
+		router.Post("/verify", s.VerifyEmailHandler)
+		router.Post("/verify/resend", s.ResendVerificationHandler)
+		router.With(authorization).Post("/logout", s.LogoutHandler)
 	})
 
 	// Users
 	r.Route("/users", func(router chi.Router) {
-		router.Use(middleware.Authorization)
-		router.With(middleware.RequirePermission("create_users")).Post("/create", AddUser)
-		router.With(middleware.RequirePermission("view_users")).Get("/get", GetUserByID)        // expects ?id=
-		router.With(middleware.RequirePermission("edit_users")).Put("/update", UpdateUser)      // expects full user JSON body with ID
-		router.With(middleware.RequirePermission("delete_users")).Delete("/delete", DeleteUser) // expects ?id=
+		router.Use(authorization)
+		router.With(requirePermission("create_users")).Post("/create", s.AddUser)
+		router.With(requirePermission("view_users")).Get("/get", s.GetUserByID)                          // expects ?id=
+		router.With(requirePermission("edit_users")).Put("/update", s.UpdateUser)                        // expects full user JSON body with ID
+		router.With(requirePermission("delete_users")).Delete("/delete", s.DeleteUser)                   // expects ?id=
+		router.With(requirePermission("delete_users")).Post("/restore", s.RestoreUser)                   // expects ?id=
+		router.With(requirePermission("revoke_sessions")).Post("/revoke_sessions", s.RevokeUserSessions) // expects ?id=
 	})
 
 	// Roles
 	r.Route("/roles", func(router chi.Router) {
-		router.Use(middleware.Authorization)
-		router.With(middleware.RequirePermission("create_roles")).Post("/create", AddRole)
-		router.With(middleware.RequirePermission("view_roles")).Get("/get", GetRoleByID) // expects ?id=
-		router.With(middleware.RequirePermission("edit_roles")).Put("/update", UpdateRole)
-		router.With(middleware.RequirePermission("delete_roles")).Delete("/delete", DeleteRole) // expects ?id=
+		router.Use(authorization)
+		router.With(requirePermission("create_roles")).Post("/create", s.AddRole)
+		router.With(requirePermission("view_roles")).Get("/get", s.GetRoleByID) // expects ?id=
+		router.With(requirePermission("edit_roles")).Put("/update", s.UpdateRole)
+		router.With(requirePermission("delete_roles")).Delete("/delete", s.DeleteRole) // expects ?id=
 	})
 
 	// Permissions
 	r.Route("/permissions", func(router chi.Router) {
-		router.Use(middleware.Authorization)
-		router.With(middleware.RequirePermission("create_permissions")).Post("/create", AddPermission)
-		router.With(middleware.RequirePermission("view_permissions")).Get("/get", GetPermissionByID) // expects ?id=
-		router.With(middleware.RequirePermission("edit_permissions")).Put("/update", UpdatePermission)
-		router.With(middleware.RequirePermission("delete_permissions")).Delete("/delete", DeletePermission) // expects ?id=
+		router.Use(authorization)
+		router.With(requirePermission("create_permissions")).Post("/create", s.AddPermission)
+		router.With(requirePermission("view_permissions")).Get("/get", s.GetPermissionByID) // expects ?id=
+		router.With(requirePermission("edit_permissions")).Put("/update", s.UpdatePermission)
+		router.With(requirePermission("delete_permissions")).Delete("/delete", s.DeletePermission) // expects ?id=
 	})
 
 	// User-Role Assignments
 	r.Route("/user_roles", func(router chi.Router) {
-		router.Use(middleware.Authorization)
-		router.With(middleware.RequirePermission("assign_roles")).Post("/assign", AssignUserRole)
-		router.With(middleware.RequirePermission("unassign_roles")).Delete("/remove", RemoveUserRole) // expects ?user_id=&role_id=
+		router.Use(authorization)
+		router.With(requirePermission("assign_roles")).Post("/assign", s.AssignUserRole)
+		router.With(requirePermission("unassign_roles")).Delete("/remove", s.RemoveUserRole) // expects ?user_id=&role_id=
 	})
 
 	// Role-Permission Assignments
 	r.Route("/role_permissions", func(router chi.Router) {
-		router.Use(middleware.Authorization)
-		router.With(middleware.RequirePermission("assign_permissions")).Post("/assign", AssignRolePermission)
-		router.With(middleware.RequirePermission("unassign_permissions")).Delete("/remove", RemoveRolePermission) // expects ?role_id=&permission_id=
+		router.Use(authorization)
+		router.With(requirePermission("assign_permissions")).Post("/assign", s.AssignRolePermission)
+		router.With(requirePermission("unassign_permissions")).Delete("/remove", s.RemoveRolePermission) // expects ?role_id=&permission_id=
 	})
 
 	r.Route("/firm", func(router chi.Router) {
-		router.Use(middleware.Authorization)
-		router.With(middleware.RequirePermission("create_firms")).Post("/submit", AddFirm)
-		router.With(middleware.RequirePermission("view_firms")).Get("/get", GetAllFirms)
+		router.Use(authorization)
+		router.With(requirePermission("create_firms")).Post("/submit", s.AddFirm)
+		router.With(requirePermission("view_firms")).Get("/get", s.GetAllFirms) // optional ?id= for a single firm with contacts
+		router.With(requirePermission("edit_firms")).Put("/update", s.UpdateFirm)
+		router.With(requirePermission("delete_firms")).Delete("/delete", s.DeleteFirm) // expects ?id=
+		router.With(requirePermission("delete_firms")).Post("/restore", s.RestoreFirm) // expects ?id=
+		router.With(requirePermission("edit_firms")).Put("/contact_relation", s.SetFirmContactRelation)
+		router.With(requirePermission("view_tickets")).Get("/tickets", s.GetTicketsByFirm) // expects ?firm_id=
 	})
 
 	r.Route("/contact", func(router chi.Router) {
-		router.Use(middleware.Authorization)
-		router.With(middleware.RequirePermission("create_contacts")).Post("/submit", AddContact)
-		router.With(middleware.RequirePermission("view_contacts")).Get("/get", GetAllContacts)
+		router.Use(authorization)
+		router.With(requirePermission("create_contacts")).Post("/submit", s.AddContact)
+		router.With(requirePermission("view_contacts")).Get("/get", s.GetAllContacts) // optional ?id= for a single contact with firms
+		router.With(requirePermission("edit_contacts")).Put("/update", s.UpdateContact)
+		router.With(requirePermission("delete_contacts")).Delete("/delete", s.DeleteContact)  // expects ?id=
+		router.With(requirePermission("delete_contacts")).Post("/restore", s.RestoreContact)  // expects ?id=
+		router.With(requirePermission("view_firms")).Get("/firms", s.GetFirmsByContactID)     // expects ?id=
+		router.With(requirePermission("view_tickets")).Get("/tickets", s.GetTicketsByContact) // expects ?contact_id=
+	})
+
+	// Tickets
+	r.Route("/tickets", func(router chi.Router) {
+		router.Use(authorization)
+		router.With(requirePermission("create_tickets")).Post("/create", s.AddTicket)
+		router.With(requirePermission("view_tickets")).Get("/get", s.GetTicketByID) // expects ?id=
+		router.With(requirePermission("view_tickets")).Get("/list", s.ListTickets)
+		router.With(requirePermission("edit_tickets")).Put("/update", s.UpdateTicket)
+		router.With(requirePermission("delete_tickets")).Delete("/delete", s.DeleteTicket) // expects ?id=
+		router.With(requirePermission("edit_tickets")).Post("/transition", s.TransitionTicket)
+		router.With(requirePermission("view_tickets")).Get("/transitions", s.GetTicketTransitions) // expects ?ticket_id=
+		router.With(requirePermission("edit_tickets")).Post("/comments", s.AddTicketComment)
+		router.With(requirePermission("view_tickets")).Get("/comments", s.GetTicketComments)                    // expects ?ticket_id=
+		router.With(requirePermission("view_tickets")).Get("/breaching", s.GetBreachingTickets)                 // optional ?within=<minutes>
+		router.With(requirePermission("edit_tickets")).Post("/attachments", s.UploadTicketAttachment)           // multipart: ticket_id, comment_id?, file...
+		router.With(requirePermission("view_tickets")).Get("/attachments", s.ListTicketAttachments)             // expects ?ticket_id=
+		router.With(requirePermission("view_tickets")).Get("/attachments/download", s.DownloadTicketAttachment) // expects ?id=
+		router.With(requirePermission("edit_tickets")).Post("/assign", s.AssignTicket)
+		router.With(requirePermission("view_tickets")).Get("/mine", s.GetMyTickets) // optional ?all=true
+	})
+
+	// Support Queues
+	r.Route("/queues", func(router chi.Router) {
+		router.Use(authorization)
+		router.With(requirePermission("manage_queues")).Post("/create", s.AddQueue)
+		router.With(requirePermission("view_tickets")).Get("/get", s.GetQueueByID) // expects ?id=
+		router.With(requirePermission("view_tickets")).Get("/list", s.ListQueues)
+		router.With(requirePermission("manage_queues")).Put("/update", s.UpdateQueue)
+		router.With(requirePermission("manage_queues")).Delete("/delete", s.DeleteQueue) // expects ?id=
+	})
+
+	// Unified search across firms, contacts, tickets and devices
+	r.With(authorization).Get("/search", s.Search) // expects ?q=, optional ?types=&limit=
+
+	// Audit log of all changes to firms, contacts, users, roles, permissions and devices
+	r.With(authorization, requirePermission("view_audit_log")).Get("/audit", s.GetAuditLog) // optional ?entity=&id=&user=&from=&to=&limit=
+
+	// Inbound email (authenticated by INBOUND_MAIL_TOKEN, not by user JWT)
+	r.Route("/mail", func(router chi.Router) {
+		router.Post("/inbound", s.InboundMail) // raw RFC 5322 message as request body
+	})
+
+	// SLA Policies
+	r.Route("/sla", func(router chi.Router) {
+		router.Use(authorization)
+		router.With(requirePermission("manage_sla")).Post("/create", s.AddSLAPolicy)
+		router.With(requirePermission("view_tickets")).Get("/get", s.GetSLAPolicyByID) // expects ?id=
+		router.With(requirePermission("view_tickets")).Get("/list", s.ListSLAPolicies)
+		router.With(requirePermission("manage_sla")).Put("/update", s.UpdateSLAPolicy)
+		router.With(requirePermission("manage_sla")).Delete("/delete", s.DeleteSLAPolicy) // expects ?id=
+		router.With(requirePermission("manage_sla")).Put("/assign", s.AssignSLAPolicy)
+	})
+
+	// Ticket Workflows
+	r.Route("/workflows", func(router chi.Router) {
+		router.Use(authorization)
+		router.With(requirePermission("manage_workflows")).Post("/create", s.AddWorkflow)
+		router.With(requirePermission("view_tickets")).Get("/get", s.GetWorkflowByID) // expects ?id=
+		router.With(requirePermission("view_tickets")).Get("/list", s.ListWorkflows)
+		router.With(requirePermission("manage_workflows")).Put("/update", s.UpdateWorkflow)
+		router.With(requirePermission("manage_workflows")).Delete("/delete", s.DeleteWorkflow) // expects ?id=
 	})
 
-	// ✅ Devices (no permission middleware)
+	// ✅ Devices
 	r.Route("/devices", func(router chi.Router) {
-		router.Post("/create", AddDevice)
-		router.Get("/get", GetDeviceByID) // expects ?id=
-		router.Get("/list", ListDevices)
-		router.Put("/update", UpdateDevice)
-		router.Delete("/delete", DeleteDevice) // expects ?id=
+		router.Use(authorization)
+		router.With(requirePermission("create_devices")).Post("/create", s.AddDevice)
+		router.With(requirePermission("view_devices")).Get("/get", s.GetDeviceByID) // expects ?id=
+		router.With(requirePermission("view_devices")).Get("/list", s.ListDevices)
+		router.With(requirePermission("edit_devices")).Put("/update", s.UpdateDevice)
+		router.With(requirePermission("view_devices")).Get("/history", s.GetDeviceHistory)                                      // expects ?id=
+		router.With(requirePermission("edit_devices")).Post("/revert", s.RevertDevice)                                          // expects {"device_id", "version"}
+		router.With(requirePermission("delete_devices")).Delete("/delete", s.DeleteDevice)                                      // expects ?id=
+		router.With(requirePermission("delete_devices")).Post("/restore", s.RestoreDevice)                                      // expects ?id=
+		router.With(requirePermission("view_devices"), requirePermission("view_tickets")).Get("/tickets", s.GetTicketsByDevice) // expects ?device_id=
 	})
 
-	// ✅ Device Links (no permission middleware)
+	// ✅ Device Links
 	r.Route("/device_links", func(router chi.Router) {
-		router.Post("/create", AddDeviceLink)
-		router.Put("/update", UpdateDeviceLink)
-		router.Get("/list", ListDeviceLinks)
-		router.Get("/get", GetDeviceLinksForDevice) // expects ?device_id=
-		router.Delete("/delete", DeleteDeviceLink)  // expects ?id=
+		router.Use(authorization)
+		router.With(requirePermission("manage_device_links")).Post("/create", s.AddDeviceLink)
+		router.With(requirePermission("manage_device_links")).Put("/update", s.UpdateDeviceLink)
+		router.With(requirePermission("view_devices")).Get("/list", s.ListDeviceLinks)
+		router.With(requirePermission("view_devices")).Get("/get", s.GetDeviceLinksForDevice)       // expects ?device_id=
+		router.With(requirePermission("manage_device_links")).Delete("/delete", s.DeleteDeviceLink) // expects ?id=
 	})
 }
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"address_module/internal/model"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// parseAuditTime accepts RFC 3339 timestamps and plain dates (YYYY-MM-DD)
+func parseAuditTime(value string) (*time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetAuditLog returns audit entries, newest first. Optional filters:
+// ?entity= (entity type), ?id= (entity ID), ?user= (actor), ?from= and ?to=
+// (RFC 3339 or YYYY-MM-DD; to is exclusive) and ?limit=.
+func (s *Server) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	filter := model.AuditFilter{EntityType: q.Get("entity"), Limit: model.DefaultListLimit}
+
+	if v := q.Get("id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "invalid_id", "id must be a number")
+			return
+		}
+		filter.EntityID = &id
+	}
+	if v := q.Get("user"); v != "" {
+		userID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "invalid_id", "user must be a number")
+			return
+		}
+		filter.UserID = &userID
+	}
+	for param, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if v := q.Get(param); v != "" {
+			t, err := parseAuditTime(v)
+			if err != nil {
+				ErrorResponse(w, http.StatusBadRequest, "invalid_parameter", param+" must be RFC 3339 or YYYY-MM-DD")
+				return
+			}
+			*dst = t
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > model.MaxListLimit {
+			ErrorResponse(w, http.StatusBadRequest, "invalid_parameter",
+				"limit must be between 1 and "+strconv.Itoa(model.MaxListLimit))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := s.DB.GetAuditEntries(filter)
+	if err != nil {
+		log.Errorf("GetAuditEntries failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch audit log")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
@@ -2,9 +2,9 @@ package handlers
 
 import (
 	"address_module/internal/model"
-	"address_module/internal/tools"
 	"encoding/json"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,10 +13,8 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-var jwtSecret = []byte("super_secret_change_me")
-
 // LoginHandler supports login with either username or email + password
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -28,20 +26,13 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := tools.NewDatabase(5, 3*time.Second)
-	if err != nil {
-		log.Error("Database connection failed: ", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
 	var user *model.User
+	var err error
 
 	if strings.Contains(creds.Identifier, "@") {
-		user, err = db.GetUserByEmail(creds.Identifier)
+		user, err = s.DB.GetUserByEmail(creds.Identifier)
 	} else {
-		user, err = db.GetUserByUsername(creds.Identifier)
+		user, err = s.DB.GetUserByUsername(creds.Identifier)
 	}
 
 	if err != nil || user == nil {
@@ -55,26 +46,52 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := generateJWT(user.ID)
+	if s.Account.RequireVerification && user.EmailVerifiedAt == nil {
+		http.Error(w, "Email address not verified", http.StatusForbidden)
+		return
+	}
+
+	resp, err := s.startSession(user.ID)
 	if err != nil {
 		log.Error("JWT generation failed: ", err)
 		http.Error(w, "Could not generate token", http.StatusInternalServerError)
 		return
 	}
 
-	resp := model.LoginResponse{Token: token}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
-// JWT generator
-func generateJWT(userID int64) (string, error) {
+// JWT generator. jti identifies the token on the revocation list and sid the
+// session (refresh token family) it belongs to. With JWT_EMBED_PERMISSIONS the
+// token also carries the user's permissions and the grant version they were read at.
+func (s *Server) generateJWT(userID int64, jti, sid string, issuedAt, expiresAt time.Time) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 2).Unix(),
-		"iat":     time.Now().Unix(),
+		"jti":     jti,
+		"sid":     sid,
+		"exp":     expiresAt.Unix(),
+		"iat":     issuedAt.Unix(),
+	}
+
+	if s.Permissions.EmbedInToken() {
+		// Read the version first so a change racing with the lookup leaves the token stale
+		version, err := s.Permissions.Version()
+		if err != nil {
+			return "", err
+		}
+		granted, err := s.Permissions.Granted(userID)
+		if err != nil {
+			return "", err
+		}
+		perms := make([]string, 0, len(granted))
+		for name := range granted {
+			perms = append(perms, name)
+		}
+		sort.Strings(perms)
+		claims["perms"] = perms
+		claims["pv"] = version
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return s.Tokens.Sign(claims)
 }
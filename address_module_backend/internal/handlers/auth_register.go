@@ -13,7 +13,7 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func RegisterHandler(w http.ResponseWriter, r *http.Request) {
+func (s *Server) RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -41,14 +41,6 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, err := tools.NewDatabase(5, 3*time.Second)
-	if err != nil {
-		log.Error("DB connection error: ", err)
-		http.Error(w, "Internal error", http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
 	// Create user
 	user := model.User{
 		Username:       req.Username,
@@ -57,27 +49,35 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		CreatedAt:      time.Now(),
 	}
 
-	userID, err := db.InsertUser(user)
+	userID, err := s.DB.InsertUser(user)
 	if err != nil {
 		log.Error("Failed to insert user during registration: ", err)
 		http.Error(w, "Registration failed", http.StatusInternalServerError)
 		return
 	}
 	log.Infof("✅ Registered new user %s (ID: %d)", user.Username, userID)
+	user.ID = userID
+	s.recordAudit(r, model.AuditActionCreate, model.AuditEntityUser, userID, nil, user)
 
 	// Optionally assign default role
 	defaultRoleName := "user"
-	role, err := db.GetRoleByName(defaultRoleName)
+	role, err := s.DB.GetRoleByName(defaultRoleName)
 	if err == nil {
-		err = db.InsertUserRole(model.UserRole{
+		userRole := model.UserRole{
 			UserID: userID,
 			RoleID: role.ID,
-		})
-		if err != nil {
+		}
+		if err := s.DB.InsertUserRole(userRole); err != nil {
 			log.Warnf("Could not assign default role %s to new user: %v", defaultRoleName, err)
+		} else {
+			s.recordAudit(r, model.AuditActionCreate, model.AuditEntityUserRole, userID, nil, userRole)
 		}
 	}
 
+	if err := s.sendUserToken(user, tools.UserTokenEmailVerification); err != nil {
+		log.Errorf("Failed to send verification email to user %d: %v", userID, err)
+	}
+
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "Registration successful",
@@ -1,31 +1,84 @@
 package handlers
 
 import (
-	"address_module/internal/tools"
+	"address_module/internal/audit"
+	"address_module/internal/middleware"
+	"address_module/internal/notifier"
+	"errors"
 	"net/http"
-	"time"
 
+	"github.com/go-sql-driver/mysql"
 	log "github.com/sirupsen/logrus"
 )
 
-// getDBInstance returns a live database connection or handles the error and response
-func getDBInstance(w http.ResponseWriter) (*tools.MySQLDB, bool) {
-	db, err := tools.NewDatabase(5, 3*time.Second)
-	if err != nil {
-		log.Errorf("Failed to connect to database: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return nil, false
+// isForeignKeyError reports whether err is a MySQL foreign key violation (missing parent row)
+func isForeignKeyError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1452
+}
+
+// sameID reports whether two optional IDs refer to the same row (or are both unset)
+func sameID(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// notify queues ticket notifications in the email outbox, in the language from
+// the notifier configuration. Failures are logged and never fail the request
+// that triggered them.
+func (s *Server) notify(store notifier.Store, queue func(n *notifier.Notifier) error) {
+	if err := queue(notifier.New(store, s.Notify.Language)); err != nil {
+		log.Errorf("Failed to queue notification: %v", err)
 	}
-	return db, true
 }
 
-// getPostgresDBInstance returns a live PostgreSQL database connection or handles the error and response
-func getPostgresDBInstance(w http.ResponseWriter) (*tools.PostgresDB, bool) {
-	db, err := tools.NewPostgresDatabase(5, 3*time.Second)
+// recordAudit appends a change made by the logged-in user to the audit log.
+// Failures are logged and never fail the request that made the change.
+func (s *Server) recordAudit(r *http.Request, action, entityType string, entityID int64, before, after interface{}) {
+	recordAudit(s.DB, r, action, entityType, entityID, before, after)
+}
+
+// recordAudit is Server.recordAudit for handlers writing through their own store
+func recordAudit(store audit.Store, r *http.Request, action, entityType string, entityID int64, before, after interface{}) {
+	if err := audit.Record(store, currentUserID(r), action, entityType, entityID, before, after); err != nil {
+		log.WithFields(log.Fields{"action": action, "entity_type": entityType, "entity_id": entityID}).
+			Errorf("Failed to write audit entry: %v", err)
+	}
+}
+
+// currentUserID returns the logged-in user, or nil on routes without Authorization
+func currentUserID(r *http.Request) *int64 {
+	if id, ok := r.Context().Value(middleware.UserIDKey).(int64); ok {
+		return &id
+	}
+	return nil
+}
+
+// includeDeleted reports whether ?include_deleted=true was requested by a user
+// holding view_deleted. If the user may not see deleted rows it writes the
+// error response and returns ok=false.
+func (s *Server) includeDeleted(w http.ResponseWriter, r *http.Request) (include bool, ok bool) {
+	if r.URL.Query().Get("include_deleted") != "true" {
+		return false, true
+	}
+
+	userID := currentUserID(r)
+	if userID == nil {
+		ErrorResponse(w, http.StatusUnauthorized, "unauthorized", "include_deleted requires a logged-in user")
+		return false, false
+	}
+
+	granted, err := s.Permissions.Granted(*userID)
 	if err != nil {
-		log.Errorf("Failed to connect to PostgreSQL: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return nil, false
+		log.Errorf("Loading permissions failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not load permissions")
+		return false, false
+	}
+	if granted["view_deleted"] {
+		return true, true
 	}
-	return db, true
+	ErrorResponse(w, http.StatusForbidden, "forbidden", "include_deleted requires the view_deleted permission")
+	return false, false
 }
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"address_module/internal/model"
+	"address_module/internal/tools"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	log "github.com/sirupsen/logrus"
+)
+
+// writeContactDetail responds with a contact and its firms
+func writeContactDetail(w http.ResponseWriter, db *tools.MySQLDB, contactID int64, includeDeleted bool) {
+	contact, err := db.GetContactByID(contactID, includeDeleted)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Contact not found")
+			return
+		}
+		log.Errorf("GetContactByID failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch contact")
+		return
+	}
+
+	firms, err := db.GetFirmsByContactID(contactID)
+	if err != nil {
+		log.Errorf("GetFirmsByContactID failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch contact firms")
+		return
+	}
+
+	detail := model.ContactDetail{
+		ContactResponse: contactResponse(*contact),
+		Firms:           []model.ContactFirmResponse{},
+	}
+	for _, f := range firms {
+		detail.Firms = append(detail.Firms, contactFirmResponse(f))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// GetContactByID returns a single contact (?id=) including its firms
+func (s *Server) GetContactByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	contactID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Contact ID must be a number")
+		return
+	}
+	include, ok := s.includeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	writeContactDetail(w, s.DB, contactID, include)
+}
+
+// UpdateContact replaces all fields of a contact and adds or removes firm
+// relations (add_firms, remove_firms) in the same transaction
+func (s *Server) UpdateContact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only PUT allowed")
+		return
+	}
+
+	var params model.ContactUpdateParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+	if params.ID == 0 {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "Contact ID is required")
+		return
+	}
+	if strings.TrimSpace(params.Vorname) == "" || strings.TrimSpace(params.Email) == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "vorname and email are required")
+		return
+	}
+
+	contact := tools.ContactParams{
+		ID:         params.ID,
+		Anrede:     params.Anrede,
+		Vorname:    params.Vorname,
+		Nachname:   params.Nachname,
+		Position:   params.Position,
+		Telefon:    params.Telefon,
+		Mobil:      params.Mobil,
+		Email:      params.Email,
+		Abteilung:  params.Abteilung,
+		Geburtstag: params.Geburtstag,
+		Bemerkung:  params.Bemerkung,
+		Kontotyp:   params.Kontotyp,
+	}
+
+	before, err := s.DB.GetContactByID(params.ID, false)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Contact not found")
+			return
+		}
+		log.Errorf("GetContactByID failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch contact")
+		return
+	}
+
+	if err := s.DB.UpdateContact(contact, params.AddFirms, params.RemoveFirms); err != nil {
+		var mysqlErr *mysql.MySQLError
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Contact not found")
+		case errors.Is(err, tools.ErrFirmNotFound):
+			ErrorResponse(w, http.StatusBadRequest, "invalid_reference", err.Error())
+		case errors.As(err, &mysqlErr) && mysqlErr.Number == 1062:
+			ErrorResponse(w, http.StatusConflict, "duplicate_entry", "A contact with this email already exists")
+		default:
+			log.Errorf("Update contact failed: %v", err)
+			ErrorResponse(w, http.StatusInternalServerError, "update_failed", "Could not update contact")
+		}
+		return
+	}
+	s.recordAudit(r, model.AuditActionUpdate, model.AuditEntityContact, params.ID, contactResponse(*before), params)
+
+	writeContactDetail(w, s.DB, params.ID, false)
+}
+
+// DeleteContact soft-deletes a contact (?id=); its firm relations are kept for a restore
+func (s *Server) DeleteContact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only DELETE allowed")
+		return
+	}
+
+	contactID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Contact ID must be a number")
+		return
+	}
+
+	contact, err := s.DB.GetContactByID(contactID, false)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Contact not found")
+			return
+		}
+		log.Errorf("GetContactByID failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch contact")
+		return
+	}
+
+	if err := s.DB.SoftDeleteContact(contactID, currentUserID(r)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Contact not found")
+			return
+		}
+		log.Errorf("Delete contact failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not delete contact")
+		return
+	}
+	s.recordAudit(r, model.AuditActionDelete, model.AuditEntityContact, contactID, contactResponse(*contact), nil)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Contact deleted successfully",
+	})
+}
+
+// RestoreContact brings back a soft-deleted contact (?id=)
+func (s *Server) RestoreContact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
+		return
+	}
+
+	contactID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Contact ID must be a number")
+		return
+	}
+
+	if err := s.DB.RestoreContact(contactID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "No deleted contact with this ID")
+			return
+		}
+		log.Errorf("Restore contact failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not restore contact")
+		return
+	}
+	s.recordAudit(r, model.AuditActionRestore, model.AuditEntityContact, contactID, nil, nil)
+
+	writeContactDetail(w, s.DB, contactID, false)
+}
@@ -2,19 +2,22 @@ package handlers
 
 import (
 	"bytes"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 
+	"address_module/internal/model"
 	"address_module/internal/tools"
 
 	log "github.com/sirupsen/logrus"
 )
 
 // AddDevice handles creating a new device
-func AddDevice(w http.ResponseWriter, r *http.Request) {
+func (s *Server) AddDevice(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
 		return
@@ -40,13 +43,7 @@ func AddDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dbi, ok := getPostgresDBInstance(w)
-	if !ok {
-		return
-	}
-	defer dbi.Close()
-
-	id, err := dbi.InsertDevice(&device)
+	id, err := s.PG.InsertDevice(&device)
 	if err != nil {
 		log.Errorf("InsertDevice failed: %v", err)
 		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not insert device")
@@ -54,12 +51,14 @@ func AddDevice(w http.ResponseWriter, r *http.Request) {
 	}
 
 	device.ID = id
+	s.recordAudit(r, model.AuditActionCreate, model.AuditEntityDevice, id, nil, device)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(device)
 }
 
 // GetDeviceByID retrieves a device by ID
-func GetDeviceByID(w http.ResponseWriter, r *http.Request) {
+func (s *Server) GetDeviceByID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
 		return
@@ -76,14 +75,12 @@ func GetDeviceByID(w http.ResponseWriter, r *http.Request) {
 		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Device ID must be a number")
 		return
 	}
-
-	dbi, ok := getPostgresDBInstance(w)
+	include, ok := s.includeDeleted(w, r)
 	if !ok {
 		return
 	}
-	defer dbi.Close()
 
-	device, err := dbi.GetDeviceByID(id)
+	device, err := s.PG.GetDeviceByID(id, include)
 	if err != nil {
 		ErrorResponse(w, http.StatusNotFound, "not_found", "Device not found")
 		return
@@ -94,7 +91,7 @@ func GetDeviceByID(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateDevice modifies an existing device
-func UpdateDevice(w http.ResponseWriter, r *http.Request) {
+func (s *Server) UpdateDevice(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only PUT allowed")
 		return
@@ -106,24 +103,30 @@ func UpdateDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dbi, ok := getPostgresDBInstance(w)
-	if !ok {
+	before, err := s.PG.GetDeviceByID(device.ID, false)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Device not found")
 		return
 	}
-	defer dbi.Close()
 
-	if err := dbi.UpdateDevice(&device); err != nil {
+	if err := s.PG.UpdateDevice(&device, currentUserID(r)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Device not found")
+			return
+		}
 		log.Errorf("UpdateDevice failed: %v", err)
 		ErrorResponse(w, http.StatusInternalServerError, "update_failed", "Device could not be updated")
 		return
 	}
+	device.CreatedAt = before.CreatedAt // not changed by UpdateDevice
+	s.recordAudit(r, model.AuditActionUpdate, model.AuditEntityDevice, device.ID, before, device)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Device updated successfully"})
 }
 
-// DeleteDevice removes a device
-func DeleteDevice(w http.ResponseWriter, r *http.Request) {
+// DeleteDevice soft-deletes a device; its links are kept for a restore
+func (s *Server) DeleteDevice(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only DELETE allowed")
 		return
@@ -141,41 +144,95 @@ func DeleteDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dbi, ok := getPostgresDBInstance(w)
-	if !ok {
+	device, err := s.PG.GetDeviceByID(id, false)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Device not found")
 		return
 	}
-	defer dbi.Close()
 
-	if err := dbi.DeleteDevice(id); err != nil {
-		ErrorResponse(w, http.StatusNotFound, "not_found", "Device not found")
+	// Tickets live in MySQL: flag their links (with a final snapshot) before the
+	// device disappears, and undo the flag if the delete itself fails.
+	if err := s.DB.MarkDeviceDeleted(tools.TicketDeviceSnapshot(device)); err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not update tickets linked to device")
 		return
 	}
 
+	if err := s.PG.SoftDeleteDevice(id, currentUserID(r)); err != nil {
+		log.Errorf("SoftDeleteDevice failed: %v", err)
+		if err := s.DB.UnmarkDeviceDeleted(id); err != nil {
+			log.Errorf("Could not restore ticket links for device %d: %v", id, err)
+		}
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Device could not be deleted")
+		return
+	}
+	s.recordAudit(r, model.AuditActionDelete, model.AuditEntityDevice, id, device, nil)
+
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Device deleted successfully"})
 }
 
-// ListDevices returns all devices
-func ListDevices(w http.ResponseWriter, r *http.Request) {
+// RestoreDevice brings back a soft-deleted device (?id=) and clears the
+// deleted flag on its ticket links
+func (s *Server) RestoreDevice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Device ID must be a number")
+		return
+	}
+
+	if err := s.PG.RestoreDevice(id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "No deleted device with this ID")
+			return
+		}
+		log.Errorf("RestoreDevice failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Device could not be restored")
+		return
+	}
+
+	if err := s.DB.UnmarkDeviceDeleted(id); err != nil {
+		log.Errorf("Could not restore ticket links for device %d: %v", id, err)
+	}
+	s.recordAudit(r, model.AuditActionRestore, model.AuditEntityDevice, id, nil, nil)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Device restored successfully"})
+}
+
+// ListDevices returns one page of devices; see model.ParseListQuery for the parameters
+func (s *Server) ListDevices(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
 		return
 	}
 
-	dbi, ok := getPostgresDBInstance(w)
+	query, err := model.ParseListQuery(r.URL.Query(), tools.DeviceListSpec)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+	include, ok := s.includeDeleted(w, r)
 	if !ok {
 		return
 	}
-	defer dbi.Close()
+	query.IncludeDeleted = include
 
-	devices, err := dbi.GetAllDevices()
+	devices, meta, err := s.PG.ListDevices(query)
 	if err != nil {
-		log.Errorf("GetAllDevices failed: %v", err)
+		log.Errorf("ListDevices failed: %v", err)
 		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch devices")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(devices)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"devices": devices,
+		"count":   len(devices),
+		"meta":    meta,
+	})
 }
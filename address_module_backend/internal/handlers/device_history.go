@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"address_module/internal/model"
+	"address_module/internal/tools"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// GetDeviceHistory returns the versions of a device (?id=), oldest first,
+// with the fields each version changed
+func (s *Server) GetDeviceHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Device ID must be a number")
+		return
+	}
+	include, ok := s.includeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := s.PG.GetDeviceByID(id, include); err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Device not found")
+		return
+	}
+
+	versions, err := s.PG.GetDeviceHistory(id)
+	if err != nil {
+		log.Errorf("GetDeviceHistory failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch device history")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"device_id": id,
+		"versions":  versions,
+		"count":     len(versions),
+	})
+}
+
+// RevertDevice restores the fields of an earlier version of a device. The
+// revert shows up in the history as a new version.
+func (s *Server) RevertDevice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
+		return
+	}
+
+	var req model.DeviceRevertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+	if req.DeviceID == 0 || req.Version < 1 {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "device_id and version are required")
+		return
+	}
+
+	before, err := s.PG.GetDeviceByID(req.DeviceID, false)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Device not found")
+		return
+	}
+
+	device, err := s.PG.RevertDevice(req.DeviceID, req.Version, currentUserID(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, tools.ErrDeviceVersionNotFound):
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Device version not found")
+		case errors.Is(err, sql.ErrNoRows):
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Device not found")
+		default:
+			log.Errorf("RevertDevice failed: %v", err)
+			ErrorResponse(w, http.StatusInternalServerError, "update_failed", "Device could not be reverted")
+		}
+		return
+	}
+	s.recordAudit(r, model.AuditActionUpdate, model.AuditEntityDevice, req.DeviceID, before, device)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(device)
+}
@@ -5,13 +5,14 @@ import (
 	"net/http"
 	"strconv"
 
+	"address_module/internal/model"
 	"address_module/internal/tools"
 
 	log "github.com/sirupsen/logrus"
 )
 
 // AddDeviceLink creates a new link between two devices
-func AddDeviceLink(w http.ResponseWriter, r *http.Request) {
+func (s *Server) AddDeviceLink(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
 		return
@@ -23,13 +24,7 @@ func AddDeviceLink(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dbi, ok := getPostgresDBInstance(w)
-	if !ok {
-		return
-	}
-	defer dbi.Close()
-
-	id, err := dbi.InsertDeviceLink(&link)
+	id, err := s.PG.InsertDeviceLink(&link)
 	if err != nil {
 		log.Errorf("InsertDeviceLink failed: %v", err)
 		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not insert device link")
@@ -37,12 +32,14 @@ func AddDeviceLink(w http.ResponseWriter, r *http.Request) {
 	}
 
 	link.ID = id
+	s.recordAudit(r, model.AuditActionCreate, model.AuditEntityDeviceLink, id, nil, link)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(link)
 }
 
 // UpdateDeviceLink modifies an existing device link
-func UpdateDeviceLink(w http.ResponseWriter, r *http.Request) {
+func (s *Server) UpdateDeviceLink(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only PUT allowed")
 		return
@@ -54,24 +51,25 @@ func UpdateDeviceLink(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dbi, ok := getPostgresDBInstance(w)
-	if !ok {
+	before, err := s.PG.GetDeviceLinkByID(link.ID)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Link not found")
 		return
 	}
-	defer dbi.Close()
 
-	if err := dbi.UpdateDeviceLink(&link); err != nil {
+	if err := s.PG.UpdateDeviceLink(&link); err != nil {
 		log.Errorf("UpdateDeviceLink failed: %v", err)
 		ErrorResponse(w, http.StatusInternalServerError, "update_failed", "Device link could not be updated")
 		return
 	}
+	s.recordAudit(r, model.AuditActionUpdate, model.AuditEntityDeviceLink, link.ID, before, link)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Device link updated successfully"})
 }
 
 // DeleteDeviceLink removes a link by ID
-func DeleteDeviceLink(w http.ResponseWriter, r *http.Request) {
+func (s *Server) DeleteDeviceLink(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only DELETE allowed")
 		return
@@ -89,47 +87,57 @@ func DeleteDeviceLink(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dbi, ok := getPostgresDBInstance(w)
-	if !ok {
+	link, err := s.PG.GetDeviceLinkByID(id)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Link not found")
 		return
 	}
-	defer dbi.Close()
 
-	if err := dbi.DeleteDeviceLink(id); err != nil {
+	if err := s.PG.DeleteDeviceLink(id); err != nil {
 		ErrorResponse(w, http.StatusNotFound, "not_found", "Link not found")
 		return
 	}
+	s.recordAudit(r, model.AuditActionDelete, model.AuditEntityDeviceLink, id, link, nil)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"message": "Link deleted successfully"})
 }
 
-// ListDeviceLinks returns all links
-func ListDeviceLinks(w http.ResponseWriter, r *http.Request) {
+// ListDeviceLinks returns one page of device links
+func (s *Server) ListDeviceLinks(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
 		return
 	}
 
-	dbi, ok := getPostgresDBInstance(w)
+	query, err := model.ParseListQuery(r.URL.Query(), tools.DeviceLinkListSpec)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+	include, ok := s.includeDeleted(w, r)
 	if !ok {
 		return
 	}
-	defer dbi.Close()
+	query.IncludeDeleted = include
 
-	links, err := dbi.GetAllDeviceLinks()
+	links, meta, err := s.PG.ListDeviceLinks(query)
 	if err != nil {
-		log.Errorf("GetAllDeviceLinks failed: %v", err)
+		log.Errorf("ListDeviceLinks failed: %v", err)
 		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch links")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(links)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"links": links,
+		"count": len(links),
+		"meta":  meta,
+	})
 }
 
 // GetDeviceLinksForDevice returns links for a given device
-func GetDeviceLinksForDevice(w http.ResponseWriter, r *http.Request) {
+func (s *Server) GetDeviceLinksForDevice(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
 		return
@@ -147,13 +155,7 @@ func GetDeviceLinksForDevice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	dbi, ok := getPostgresDBInstance(w)
-	if !ok {
-		return
-	}
-	defer dbi.Close()
-
-	links, err := dbi.GetLinksForDevice(deviceID)
+	links, err := s.PG.GetLinksForDevice(deviceID)
 	if err != nil {
 		log.Errorf("GetLinksForDevice failed: %v", err)
 		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch device links")
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"address_module/internal/model"
+	"address_module/internal/tools"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// firmResponse converts a firm row into its API representation
+func firmResponse(firm tools.FirmParams) model.FirmResponse {
+	return model.FirmResponse{
+		ID:        firm.ID,
+		Anrede:    firm.Anrede,
+		Name1:     firm.Name1,
+		Name2:     firm.Name2,
+		Name3:     firm.Name3,
+		Straße:    firm.Straße,
+		Land:      firm.Land,
+		PLZ:       firm.PLZ,
+		Ort:       firm.Ort,
+		Telefon:   firm.Telefon,
+		Email:     firm.Email,
+		Website:   firm.Website,
+		Kunde:     firm.Kunde,
+		Lieferant: firm.Lieferant,
+		Gesperrt:  firm.Gesperrt,
+		Bemerkung: firm.Bemerkung,
+		FirmaTyp:  firm.FirmaTyp,
+		DeletedAt: firm.DeletedAt,
+		DeletedBy: firm.DeletedBy,
+	}
+}
+
+// contactFirmResponse converts a firm loaded through a contact, keeping the relation
+func contactFirmResponse(firm tools.FirmParams) model.ContactFirmResponse {
+	return model.ContactFirmResponse{
+		FirmResponse:         firmResponse(firm),
+		Beziehung:            firm.Relation.Beziehung,
+		Hauptansprechpartner: firm.Relation.Hauptansprechpartner,
+	}
+}
+
+// contactResponse converts a contact row into its API representation
+func contactResponse(contact tools.ContactParams) model.ContactResponse {
+	return model.ContactResponse{
+		ID:         contact.ID,
+		Anrede:     contact.Anrede,
+		Vorname:    contact.Vorname,
+		Nachname:   contact.Nachname,
+		Position:   contact.Position,
+		Telefon:    contact.Telefon,
+		Mobil:      contact.Mobil,
+		Email:      contact.Email,
+		Abteilung:  contact.Abteilung,
+		Geburtstag: contact.Geburtstag,
+		Bemerkung:  contact.Bemerkung,
+		Kontotyp:   contact.Kontotyp,
+		DeletedAt:  contact.DeletedAt,
+		DeletedBy:  contact.DeletedBy,
+	}
+}
+
+// GetFirmByID returns a single firm (?id=) including its contacts
+func (s *Server) GetFirmByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	firmID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Firm ID must be a number")
+		return
+	}
+	include, ok := s.includeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	firm, err := s.DB.GetFirmByID(firmID, include)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Firm not found")
+			return
+		}
+		log.Errorf("GetFirmByID failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch firm")
+		return
+	}
+
+	contacts, err := s.DB.GetContactsByFirmID(firmID)
+	if err != nil {
+		log.Errorf("GetContactsByFirmID failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch firm contacts")
+		return
+	}
+
+	detail := model.FirmDetail{
+		FirmResponse: firmResponse(*firm),
+		Contacts:     []model.FirmContactResponse{},
+	}
+	for _, c := range contacts {
+		detail.Contacts = append(detail.Contacts, model.FirmContactResponse{
+			ContactResponse:      contactResponse(c),
+			Beziehung:            c.Relation.Beziehung,
+			Hauptansprechpartner: c.Relation.Hauptansprechpartner,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// UpdateFirm replaces all fields of a firm. The body has the shape returned
+// by /firm/get; contacts are not changed here.
+func (s *Server) UpdateFirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only PUT allowed")
+		return
+	}
+
+	var params model.FirmResponse
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+	if params.ID == 0 {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "Firm ID is required")
+		return
+	}
+	if strings.TrimSpace(params.Anrede) == "" || strings.TrimSpace(params.Name1) == "" || strings.TrimSpace(params.PLZ) == "" ||
+		strings.TrimSpace(params.Ort) == "" || strings.TrimSpace(params.Telefon) == "" || strings.TrimSpace(params.Email) == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "anrede, name_1, plz, ort, telefon and email are required")
+		return
+	}
+
+	firm := tools.FirmParams{
+		ID:        params.ID,
+		Anrede:    params.Anrede,
+		Name1:     params.Name1,
+		Name2:     params.Name2,
+		Name3:     params.Name3,
+		Straße:    params.Straße,
+		Land:      params.Land,
+		PLZ:       params.PLZ,
+		Ort:       params.Ort,
+		Telefon:   params.Telefon,
+		Email:     params.Email,
+		Website:   params.Website,
+		Kunde:     params.Kunde,
+		Lieferant: params.Lieferant,
+		Gesperrt:  params.Gesperrt,
+		Bemerkung: params.Bemerkung,
+		FirmaTyp:  params.FirmaTyp,
+	}
+
+	before, err := s.DB.GetFirmByID(firm.ID, false)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Firm not found")
+			return
+		}
+		log.Errorf("GetFirmByID failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch firm")
+		return
+	}
+
+	if err := s.DB.UpdateFirm(firm); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Firm not found")
+			return
+		}
+		log.Errorf("Update firm failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "update_failed", "Could not update firm")
+		return
+	}
+	s.recordAudit(r, model.AuditActionUpdate, model.AuditEntityFirm, firm.ID, firmResponse(*before), firmResponse(firm))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(firmResponse(firm))
+}
+
+// DeleteFirm soft-deletes a firm (?id=). Its tickets and contact relations
+// keep pointing to it; the purge job removes it once the retention period is
+// over and it has no open tickets left.
+func (s *Server) DeleteFirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only DELETE allowed")
+		return
+	}
+
+	firmID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Firm ID must be a number")
+		return
+	}
+
+	firm, err := s.DB.GetFirmByID(firmID, false)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Firm not found")
+			return
+		}
+		log.Errorf("GetFirmByID failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch firm")
+		return
+	}
+
+	if err := s.DB.SoftDeleteFirm(firmID, currentUserID(r)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Firm not found")
+			return
+		}
+		log.Errorf("Delete firm failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not delete firm")
+		return
+	}
+	s.recordAudit(r, model.AuditActionDelete, model.AuditEntityFirm, firmID, firmResponse(*firm), nil)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Firm deleted successfully",
+	})
+}
+
+// RestoreFirm brings back a soft-deleted firm (?id=)
+func (s *Server) RestoreFirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
+		return
+	}
+
+	firmID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Firm ID must be a number")
+		return
+	}
+
+	if err := s.DB.RestoreFirm(firmID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "No deleted firm with this ID")
+			return
+		}
+		log.Errorf("Restore firm failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not restore firm")
+		return
+	}
+	s.recordAudit(r, model.AuditActionRestore, model.AuditEntityFirm, firmID, nil, nil)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Firm restored successfully",
+	})
+}
+
+// SetFirmContactRelation sets the role (beziehung) and primary-contact flag of
+// a contact at a firm, linking the two if they are not linked yet. Marking a
+// contact as primary removes the flag from the firm's previous primary contact.
+func (s *Server) SetFirmContactRelation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only PUT allowed")
+		return
+	}
+
+	var req model.FirmContactRelationParams
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+	if req.FirmaID == 0 || req.ContactID == 0 {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "firma_id and contact_id are required")
+		return
+	}
+	req.Beziehung = strings.TrimSpace(req.Beziehung)
+
+	if _, err := s.DB.GetFirmByID(req.FirmaID, false); err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Firm not found")
+		return
+	}
+
+	contacts, err := s.DB.GetContactsByFirmID(req.FirmaID)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch firm contacts")
+		return
+	}
+	var before *model.FirmContactRelationParams
+	for _, c := range contacts {
+		if c.ID == req.ContactID {
+			before = &model.FirmContactRelationParams{
+				FirmaID:              req.FirmaID,
+				ContactID:            c.ID,
+				Beziehung:            c.Relation.Beziehung,
+				Hauptansprechpartner: c.Relation.Hauptansprechpartner,
+			}
+		}
+	}
+
+	rel := tools.FirmContactRelation{Beziehung: req.Beziehung, Hauptansprechpartner: req.Hauptansprechpartner}
+	status, action := http.StatusOK, model.AuditActionUpdate
+	err = s.DB.UpdateContactFirmRelationship(req.ContactID, req.FirmaID, rel)
+	if errors.Is(err, sql.ErrNoRows) {
+		status, action = http.StatusCreated, model.AuditActionCreate
+		err = s.DB.CreateContactFirmRelationship(req.ContactID, req.FirmaID, rel)
+	}
+	if err != nil {
+		if isForeignKeyError(err) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Contact not found")
+			return
+		}
+		log.Errorf("Set firm contact relation failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "update_failed", "Could not update relationship")
+		return
+	}
+	s.recordAudit(r, action, model.AuditEntityFirmContact, req.FirmaID, before, req)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(req)
+}
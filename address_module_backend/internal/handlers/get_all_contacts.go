@@ -6,29 +6,35 @@ import (
 	"address_module/internal/tools"
 	"encoding/json"
 	"net/http"
-	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
-// GetAllContacts handles GET requests to retrieve all contacts
-func GetAllContacts(w http.ResponseWriter, r *http.Request) {
+// GetAllContacts handles GET requests to retrieve contacts, one page at a time.
+// With ?id= it returns that single contact instead.
+func (s *Server) GetAllContacts(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
 		return
 	}
+	if r.URL.Query().Has("id") {
+		s.GetContactByID(w, r)
+		return
+	}
 
-	// Connect to MySQL database
-	db, err := tools.NewDatabase(5, 3*time.Second)
+	query, err := model.ParseListQuery(r.URL.Query(), tools.ContactListSpec)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-		api.InternalErrorHandler(w)
+		ErrorResponse(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+	include, ok := s.includeDeleted(w, r)
+	if !ok {
 		return
 	}
-	defer db.Close()
+	query.IncludeDeleted = include
 
-	// Get all contacts
-	contacts, err := db.GetAllContacts()
+	// Get one page of contacts
+	contacts, meta, err := s.DB.ListContacts(query)
 	if err != nil {
 		log.Error("Failed to get contacts: ", err)
 		api.InternalErrorHandler(w)
@@ -51,6 +57,8 @@ func GetAllContacts(w http.ResponseWriter, r *http.Request) {
 			Geburtstag: contact.Geburtstag,
 			Bemerkung:  contact.Bemerkung,
 			Kontotyp:   contact.Kontotyp,
+			DeletedAt:  contact.DeletedAt,
+			DeletedBy:  contact.DeletedBy,
 		}
 		contactResponses = append(contactResponses, contactResponse)
 	}
@@ -62,6 +70,7 @@ func GetAllContacts(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"contacts": contactResponses,
 		"count":    len(contactResponses),
+		"meta":     meta,
 	}
 
 	err = json.NewEncoder(w).Encode(response)
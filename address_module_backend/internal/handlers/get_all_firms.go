@@ -6,29 +6,35 @@ import (
 	"address_module/internal/tools"
 	"encoding/json"
 	"net/http"
-	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
-// GetAllFirms handles GET requests to retrieve all firms
-func GetAllFirms(w http.ResponseWriter, r *http.Request) {
+// GetAllFirms handles GET requests to retrieve firms, one page at a time.
+// With ?id= it returns that single firm instead.
+func (s *Server) GetAllFirms(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
 		return
 	}
+	if r.URL.Query().Has("id") {
+		s.GetFirmByID(w, r)
+		return
+	}
 
-	// Connect to MySQL database
-	db, err := tools.NewDatabase(5, 3*time.Second)
+	query, err := model.ParseListQuery(r.URL.Query(), tools.FirmListSpec)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-		api.InternalErrorHandler(w)
+		ErrorResponse(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+	include, ok := s.includeDeleted(w, r)
+	if !ok {
 		return
 	}
-	defer db.Close()
+	query.IncludeDeleted = include
 
-	// Get all firms
-	firms, err := db.GetAllFirms()
+	// Get one page of firms
+	firms, meta, err := s.DB.ListFirms(query)
 	if err != nil {
 		log.Error("Failed to get firms: ", err)
 		api.InternalErrorHandler(w)
@@ -56,6 +62,8 @@ func GetAllFirms(w http.ResponseWriter, r *http.Request) {
 			Gesperrt:  firm.Gesperrt,
 			Bemerkung: firm.Bemerkung,
 			FirmaTyp:  firm.FirmaTyp,
+			DeletedAt: firm.DeletedAt,
+			DeletedBy: firm.DeletedBy,
 		}
 		firmResponses = append(firmResponses, firmResponse)
 	}
@@ -67,6 +75,7 @@ func GetAllFirms(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"firms": firmResponses,
 		"count": len(firmResponses),
+		"meta":  meta,
 	}
 
 	err = json.NewEncoder(w).Encode(response)
@@ -3,12 +3,10 @@ package handlers
 import (
 	"address_module/api"
 	"address_module/internal/model"
-	"address_module/internal/tools"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
-	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -16,7 +14,7 @@ import (
 var ErrMissingContactID = errors.New("missing required contact ID")
 
 // GetFirmsByContactID handles GET requests to retrieve all firms associated with a contact
-func GetFirmsByContactID(w http.ResponseWriter, r *http.Request) {
+func (s *Server) GetFirmsByContactID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
 		return
@@ -38,46 +36,18 @@ func GetFirmsByContactID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connect to MySQL database
-	db, err := tools.NewDatabase(5, 3*time.Second)
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
-		api.InternalErrorHandler(w)
-		return
-	}
-	defer db.Close()
-
 	// Get firms by contact ID
-	firms, err := db.GetFirmsByContactID(contactID)
+	firms, err := s.DB.GetFirmsByContactID(contactID)
 	if err != nil {
 		log.Error("Failed to get firms: ", err)
 		api.InternalErrorHandler(w)
 		return
 	}
 
-	// Convert tools.FirmParams to api.FirmResponse objects
-	var firmResponses []model.FirmResponse
+	// Convert tools.FirmParams to API objects including the contact's role at each firm
+	var firmResponses []model.ContactFirmResponse
 	for _, firm := range firms {
-		firmResponse := model.FirmResponse{
-			ID:        firm.ID, // Include the ID
-			Anrede:    firm.Anrede,
-			Name1:     firm.Name1,
-			Name2:     firm.Name2,
-			Name3:     firm.Name3,
-			Straße:    firm.Straße,
-			Land:      firm.Land,
-			PLZ:       firm.PLZ,
-			Ort:       firm.Ort,
-			Telefon:   firm.Telefon,
-			Email:     firm.Email,
-			Website:   firm.Website,
-			Kunde:     firm.Kunde,
-			Lieferant: firm.Lieferant,
-			Gesperrt:  firm.Gesperrt,
-			Bemerkung: firm.Bemerkung,
-			FirmaTyp:  firm.FirmaTyp,
-		}
-		firmResponses = append(firmResponses, firmResponse)
+		firmResponses = append(firmResponses, contactFirmResponse(firm))
 	}
 
 	// Response with the list of firms
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"address_module/internal/inbound"
+	"address_module/internal/model"
+	"address_module/internal/notifier"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultInboundMailMaxBytes = 25 << 20
+
+// InboundMail turns a raw RFC 5322 message into a ticket, or into a comment on
+// an existing ticket when the subject carries its [Ticket#123] token.
+// Callers (a local MTA pipe or a mailbox poller) authenticate with the shared
+// secret from INBOUND_MAIL_TOKEN in the X-Inbound-Token header.
+func (s *Server) InboundMail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
+		return
+	}
+
+	secret := os.Getenv("INBOUND_MAIL_TOKEN")
+	if secret == "" {
+		ErrorResponse(w, http.StatusServiceUnavailable, "disabled", "Inbound mail is not configured")
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Inbound-Token")), []byte(secret)) != 1 {
+		ErrorResponse(w, http.StatusUnauthorized, "unauthorized", "Invalid inbound mail token")
+		return
+	}
+
+	maxBytes := int64(defaultInboundMailMaxBytes)
+	if v, err := strconv.ParseInt(os.Getenv("INBOUND_MAIL_MAX_BYTES"), 10, 64); err == nil && v > 0 {
+		maxBytes = v
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+
+	msg, err := inbound.Parse(r.Body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ErrorResponse(w, http.StatusRequestEntityTooLarge, "too_large", "Message exceeds the size limit")
+			return
+		}
+		ErrorResponse(w, http.StatusBadRequest, "invalid_message", err.Error())
+		return
+	}
+
+	if previous, err := s.DB.GetInboundMail(msg.MessageID); err == nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(previous)
+		return
+	}
+
+	var contactID *int64
+	if id, err := s.DB.GetContactIDByEmail(msg.From); err == nil {
+		contactID = &id
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not resolve sender")
+		return
+	}
+
+	var result *model.InboundMailResult
+	if ticketID, found := model.ParseTicketToken(msg.Subject); found {
+		if ticket, err := s.DB.GetTicketByID(ticketID); err == nil {
+			result, err = s.threadInboundMail(ticket, msg, contactID)
+			if err != nil {
+				ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not add reply to ticket")
+				return
+			}
+		}
+	}
+	if result == nil {
+		result, err = s.createTicketFromMail(msg, contactID)
+		if err != nil {
+			ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not create ticket from mail")
+			return
+		}
+	}
+
+	if err := s.DB.RecordInboundMail(msg.MessageID, msg.From, result.TicketID, result.CommentID); err != nil {
+		log.Errorf("Inbound mail %s processed but not recorded: %v", msg.MessageID, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(result)
+}
+
+// inboundMailText is the mail body plus a list of its attachments
+func inboundMailText(msg *model.InboundMail, knownSender bool) string {
+	var b strings.Builder
+	if !knownSender {
+		fmt.Fprintf(&b, "From: %s <%s>\n\n", msg.FromName, msg.From)
+	}
+	b.WriteString(msg.Body)
+	if len(msg.Attachments) > 0 {
+		b.WriteString("\n\nAttachments:")
+		for _, a := range msg.Attachments {
+			fmt.Fprintf(&b, "\n- %s (%s, %d bytes)", a.Filename, a.ContentType, a.Size)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func (s *Server) threadInboundMail(ticket *model.Ticket, msg *model.InboundMail, contactID *int64) (*model.InboundMailResult, error) {
+	stored, err := s.DB.InsertTicketComment(model.TicketComment{
+		TicketID:        ticket.ID,
+		AuthorContactID: contactID,
+		Body:            inboundMailText(msg, contactID != nil),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	saveInboundAttachments(s.DB, ticket.ID, &stored.ID, msg.Attachments)
+	s.notify(s.DB, func(n *notifier.Notifier) error { return n.TicketCommented(*ticket, *stored) })
+	return &model.InboundMailResult{Action: "threaded", TicketID: &ticket.ID, CommentID: &stored.ID}, nil
+}
+
+func (s *Server) createTicketFromMail(msg *model.InboundMail, contactID *int64) (*model.InboundMailResult, error) {
+	title := strings.TrimSpace(model.StripTicketToken(msg.Subject))
+	if title == "" {
+		title = "(no subject)"
+	}
+	if runes := []rune(title); len(runes) > 255 {
+		title = string(runes[:255])
+	}
+
+	wf, err := s.DB.GetDefaultWorkflow()
+	if err != nil {
+		return nil, err
+	}
+
+	ticket := model.Ticket{
+		Title:       title,
+		Description: inboundMailText(msg, contactID != nil),
+		Status:      wf.InitialStatus,
+		Priority:    model.TicketPriorityNormal,
+		ContactID:   contactID,
+		WorkflowID:  &wf.ID,
+	}
+	if contactID != nil {
+		if ticket.FirmaID, err = s.DB.GetPrimaryFirmForContact(*contactID); err != nil {
+			return nil, err
+		}
+	}
+	ticket.FirstResponseDueAt, ticket.ResolutionDueAt, err = s.DB.ComputeTicketDueDates(ticket.FirmaID, ticket.Priority, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	ticketID, err := s.DB.InsertTicketWithDevices(ticket, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	saveInboundAttachments(s.DB, ticketID, nil, msg.Attachments)
+	if created, err := s.DB.GetTicketByID(ticketID); err == nil {
+		s.notify(s.DB, func(n *notifier.Notifier) error { return n.TicketCreated(*created) })
+	}
+	return &model.InboundMailResult{Action: "created", TicketID: &ticketID}, nil
+}
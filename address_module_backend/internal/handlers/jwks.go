@@ -0,0 +1,14 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// JWKS publishes the public signing keys so other services can verify our
+// tokens without calling back. HS256 keys are never listed.
+func (s *Server) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(s.Tokens.JWKS())
+}
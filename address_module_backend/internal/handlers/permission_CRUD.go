@@ -11,7 +11,7 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func AddPermission(w http.ResponseWriter, r *http.Request) {
+func (s *Server) AddPermission(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
 		return
@@ -23,13 +23,7 @@ func AddPermission(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, ok := getDBInstance(w)
-	if !ok {
-		return
-	}
-	defer db.Close()
-
-	permID, err := db.InsertPermission(perm)
+	permID, err := s.DB.InsertPermission(perm)
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
 		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
@@ -43,11 +37,13 @@ func AddPermission(w http.ResponseWriter, r *http.Request) {
 	}
 
 	perm.ID = permID
+	s.recordAudit(r, model.AuditActionCreate, model.AuditEntityPermission, permID, nil, perm)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(perm)
 }
 
-func GetPermissionByID(w http.ResponseWriter, r *http.Request) {
+func (s *Server) GetPermissionByID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
 		return
@@ -65,13 +61,7 @@ func GetPermissionByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, ok := getDBInstance(w)
-	if !ok {
-		return
-	}
-	defer db.Close()
-
-	perm, err := db.GetPermissionByID(permID)
+	perm, err := s.DB.GetPermissionByID(permID)
 	if err != nil {
 		ErrorResponse(w, http.StatusNotFound, "not_found", "Permission not found")
 		return
@@ -81,7 +71,7 @@ func GetPermissionByID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(perm)
 }
 
-func UpdatePermission(w http.ResponseWriter, r *http.Request) {
+func (s *Server) UpdatePermission(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only PUT allowed")
 		return
@@ -93,17 +83,19 @@ func UpdatePermission(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, ok := getDBInstance(w)
-	if !ok {
+	before, err := s.DB.GetPermissionByID(perm.ID)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Permission not found")
 		return
 	}
-	defer db.Close()
 
-	if err := db.UpdatePermission(perm); err != nil {
+	if err := s.DB.UpdatePermission(perm); err != nil {
 		log.Errorf("Update permission failed: %v", err)
 		ErrorResponse(w, http.StatusInternalServerError, "update_failed", "Could not update permission")
 		return
 	}
+	s.recordAudit(r, model.AuditActionUpdate, model.AuditEntityPermission, perm.ID, before, perm)
+	s.Permissions.InvalidateAll()
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -111,7 +103,7 @@ func UpdatePermission(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func DeletePermission(w http.ResponseWriter, r *http.Request) {
+func (s *Server) DeletePermission(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only DELETE allowed")
 		return
@@ -129,17 +121,19 @@ func DeletePermission(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, ok := getDBInstance(w)
-	if !ok {
+	perm, err := s.DB.GetPermissionByID(permID)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Permission not found")
 		return
 	}
-	defer db.Close()
 
-	if err := db.DeletePermission(permID); err != nil {
+	if err := s.DB.DeletePermission(permID); err != nil {
 		log.Errorf("Delete permission failed: %v", err)
 		ErrorResponse(w, http.StatusNotFound, "not_found", "Permission not found")
 		return
 	}
+	s.recordAudit(r, model.AuditActionDelete, model.AuditEntityPermission, permID, perm, nil)
+	s.Permissions.InvalidateAll()
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
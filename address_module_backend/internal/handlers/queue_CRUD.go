@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"address_module/internal/middleware"
+	"address_module/internal/model"
+	"address_module/internal/notifier"
+	"address_module/internal/tools"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+	log "github.com/sirupsen/logrus"
+)
+
+// AddQueue creates a new support queue with its members
+func (s *Server) AddQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
+		return
+	}
+
+	var queue model.Queue
+	if err := json.NewDecoder(r.Body).Decode(&queue); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+	if err := queue.Validate(); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_queue", err.Error())
+		return
+	}
+
+	queueID, err := s.DB.InsertQueue(queue)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			ErrorResponse(w, http.StatusConflict, "duplicate_entry", "Queue name already exists")
+			return
+		}
+		if isForeignKeyError(err) {
+			ErrorResponse(w, http.StatusBadRequest, "invalid_reference", "Role or member does not exist")
+			return
+		}
+
+		log.Errorf("Insert queue failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not insert queue")
+		return
+	}
+
+	queue.ID = queueID
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(queue)
+}
+
+// GetQueueByID fetches a queue with its members
+func (s *Server) GetQueueByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing queue ID")
+		return
+	}
+
+	queueID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Queue ID must be a number")
+		return
+	}
+
+	queue, err := s.DB.GetQueueByID(queueID)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Queue not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(queue)
+}
+
+// ListQueues returns all queues
+func (s *Server) ListQueues(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	queues, err := s.DB.GetAllQueues()
+	if err != nil {
+		log.Errorf("GetAllQueues failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch queues")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"queues": queues,
+		"count":  len(queues),
+	})
+}
+
+// UpdateQueue replaces a queue definition; member_ids replaces the member list
+func (s *Server) UpdateQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only PUT allowed")
+		return
+	}
+
+	var queue model.Queue
+	if err := json.NewDecoder(r.Body).Decode(&queue); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+	if queue.ID == 0 {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "Queue ID is required")
+		return
+	}
+	if err := queue.Validate(); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_queue", err.Error())
+		return
+	}
+
+	if err := s.DB.UpdateQueue(queue); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Queue not found")
+			return
+		}
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			ErrorResponse(w, http.StatusConflict, "duplicate_entry", "Queue name already exists")
+			return
+		}
+		if isForeignKeyError(err) {
+			ErrorResponse(w, http.StatusBadRequest, "invalid_reference", "Role or member does not exist")
+			return
+		}
+
+		log.Errorf("Update queue failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "update_failed", "Could not update queue")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Queue updated successfully",
+	})
+}
+
+// DeleteQueue removes a queue; its tickets stay assigned but leave the queue
+func (s *Server) DeleteQueue(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only DELETE allowed")
+		return
+	}
+
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing queue ID")
+		return
+	}
+
+	queueID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Queue ID must be a number")
+		return
+	}
+
+	if err := s.DB.DeleteQueue(queueID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Queue not found")
+			return
+		}
+		log.Errorf("Delete queue failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not delete queue")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Queue deleted successfully",
+	})
+}
+
+// AssignTicket moves a ticket into a queue and/or to a user. Without an
+// assignee, a round-robin or least-open queue picks one of its members.
+func (s *Server) AssignTicket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
+		return
+	}
+
+	var req model.TicketAssignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+	if req.TicketID == 0 {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "ticket_id is required")
+		return
+	}
+
+	existing, err := s.DB.GetTicketByID(req.TicketID)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Ticket not found")
+		return
+	}
+
+	if _, err := s.DB.AssignTicket(req.TicketID, req.QueueID, req.AssigneeID); err != nil {
+		switch {
+		case errors.Is(err, tools.ErrNotQueueMember):
+			ErrorResponse(w, http.StatusBadRequest, "not_queue_member", err.Error())
+		case errors.Is(err, sql.ErrNoRows):
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Ticket or queue not found")
+		case isForeignKeyError(err):
+			ErrorResponse(w, http.StatusBadRequest, "invalid_reference", "Assignee does not exist")
+		default:
+			log.Errorf("Assign ticket failed: %v", err)
+			ErrorResponse(w, http.StatusInternalServerError, "update_failed", "Could not assign ticket")
+		}
+		return
+	}
+
+	ticket, err := s.DB.GetTicketByID(req.TicketID)
+	if err != nil {
+		log.Errorf("Failed to reload ticket %d: %v", req.TicketID, err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not load assigned ticket")
+		return
+	}
+
+	if ticket.AssigneeID != nil && !sameID(existing.AssigneeID, ticket.AssigneeID) {
+		s.notify(s.DB, func(n *notifier.Notifier) error { return n.TicketAssigned(*ticket) })
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ticket)
+}
+
+// GetMyTickets returns the tickets assigned to the logged-in user. Resolved and
+// closed tickets are only included with ?all=true.
+func (s *Server) GetMyTickets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		ErrorResponse(w, http.StatusUnauthorized, "unauthorized", "Missing user in request context")
+		return
+	}
+	includeClosed := r.URL.Query().Get("all") == "true"
+
+	tickets, err := s.DB.GetTicketsByAssignee(userID, includeClosed)
+	if err != nil {
+		log.Errorf("GetTicketsByAssignee failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch tickets")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id": userID,
+		"tickets": tickets,
+		"count":   len(tickets),
+	})
+}
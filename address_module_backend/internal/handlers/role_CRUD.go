@@ -11,7 +11,7 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-func AddRole(w http.ResponseWriter, r *http.Request) {
+func (s *Server) AddRole(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
 		return
@@ -23,13 +23,7 @@ func AddRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, ok := getDBInstance(w)
-	if !ok {
-		return
-	}
-	defer db.Close()
-
-	roleID, err := db.InsertRole(role)
+	roleID, err := s.DB.InsertRole(role)
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
 		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
@@ -43,11 +37,13 @@ func AddRole(w http.ResponseWriter, r *http.Request) {
 	}
 
 	role.ID = roleID
+	s.recordAudit(r, model.AuditActionCreate, model.AuditEntityRole, roleID, nil, role)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(role)
 }
 
-func GetRoleByID(w http.ResponseWriter, r *http.Request) {
+func (s *Server) GetRoleByID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
 		return
@@ -65,13 +61,7 @@ func GetRoleByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, ok := getDBInstance(w)
-	if !ok {
-		return
-	}
-	defer db.Close()
-
-	role, err := db.GetRoleByID(roleID)
+	role, err := s.DB.GetRoleByID(roleID)
 	if err != nil {
 		ErrorResponse(w, http.StatusNotFound, "not_found", "Role not found")
 		return
@@ -81,7 +71,7 @@ func GetRoleByID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(role)
 }
 
-func UpdateRole(w http.ResponseWriter, r *http.Request) {
+func (s *Server) UpdateRole(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only PUT allowed")
 		return
@@ -93,17 +83,19 @@ func UpdateRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, ok := getDBInstance(w)
-	if !ok {
+	before, err := s.DB.GetRoleByID(role.ID)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Role not found")
 		return
 	}
-	defer db.Close()
 
-	if err := db.UpdateRole(role); err != nil {
+	if err := s.DB.UpdateRole(role); err != nil {
 		log.Errorf("Update role failed: %v", err)
 		ErrorResponse(w, http.StatusInternalServerError, "update_failed", "Could not update role")
 		return
 	}
+	s.recordAudit(r, model.AuditActionUpdate, model.AuditEntityRole, role.ID, before, role)
+	s.Permissions.InvalidateAll()
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -111,7 +103,7 @@ func UpdateRole(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func DeleteRole(w http.ResponseWriter, r *http.Request) {
+func (s *Server) DeleteRole(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only DELETE allowed")
 		return
@@ -129,17 +121,19 @@ func DeleteRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, ok := getDBInstance(w)
-	if !ok {
+	role, err := s.DB.GetRoleByID(roleID)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Role not found")
 		return
 	}
-	defer db.Close()
 
-	if err := db.DeleteRole(roleID); err != nil {
+	if err := s.DB.DeleteRole(roleID); err != nil {
 		log.Errorf("Delete role failed: %v", err)
 		ErrorResponse(w, http.StatusNotFound, "not_found", "Role not found")
 		return
 	}
+	s.recordAudit(r, model.AuditActionDelete, model.AuditEntityRole, roleID, role, nil)
+	s.Permissions.InvalidateAll()
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
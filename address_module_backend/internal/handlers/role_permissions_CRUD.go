@@ -10,7 +10,7 @@ import (
 )
 
 // AssignRolePermission assigns a permission to a role
-func AssignRolePermission(w http.ResponseWriter, r *http.Request) {
+func (s *Server) AssignRolePermission(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
 		return
@@ -22,17 +22,13 @@ func AssignRolePermission(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, ok := getDBInstance(w)
-	if !ok {
-		return
-	}
-	defer db.Close()
-
-	if err := db.InsertRolePermission(rp); err != nil {
+	if err := s.DB.InsertRolePermission(rp); err != nil {
 		log.Errorf("Failed to assign role-permission: %v", err)
 		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not assign permission to role")
 		return
 	}
+	s.recordAudit(r, model.AuditActionCreate, model.AuditEntityRolePermission, rp.RoleID, nil, rp)
+	s.Permissions.InvalidateAll()
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -41,7 +37,7 @@ func AssignRolePermission(w http.ResponseWriter, r *http.Request) {
 }
 
 // RemoveRolePermission deletes a role-permission mapping
-func RemoveRolePermission(w http.ResponseWriter, r *http.Request) {
+func (s *Server) RemoveRolePermission(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only DELETE allowed")
 		return
@@ -58,17 +54,14 @@ func RemoveRolePermission(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, ok := getDBInstance(w)
-	if !ok {
-		return
-	}
-	defer db.Close()
-
-	if err := db.DeleteRolePermission(roleID, permID); err != nil {
+	if err := s.DB.DeleteRolePermission(roleID, permID); err != nil {
 		log.Errorf("Failed to remove role-permission: %v", err)
 		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not remove role-permission mapping")
 		return
 	}
+	s.recordAudit(r, model.AuditActionDelete, model.AuditEntityRolePermission, roleID,
+		model.RolePermission{RoleID: roleID, PermissionID: permID}, nil)
+	s.Permissions.InvalidateAll()
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
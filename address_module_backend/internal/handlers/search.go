@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"address_module/internal/middleware"
+	"address_module/internal/model"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// searchPermissions maps each searchable type to the permission needed to see it
+var searchPermissions = map[string]string{
+	model.SearchTypeFirm:    "view_firms",
+	model.SearchTypeContact: "view_contacts",
+	model.SearchTypeTicket:  "view_tickets",
+	model.SearchTypeDevice:  "view_devices",
+}
+
+// normalizeScores scales scores to 0..1 relative to the best hit, so results
+// ranked by MySQL and Postgres can be merged into one list
+func normalizeScores(results []model.SearchResult) {
+	best := 0.0
+	for _, r := range results {
+		if r.Score > best {
+			best = r.Score
+		}
+	}
+	for i := range results {
+		if best > 0 {
+			results[i].Score /= best
+		} else {
+			results[i].Score = 0
+		}
+	}
+}
+
+// Search looks up firms, contacts, tickets and devices matching ?q=.
+// ?types=firm,contact,ticket,device narrows the search and ?limit= caps the
+// merged result list (default 20, max 100). Types the user may not view are
+// skipped; if the device database is unreachable the response is marked partial.
+func (s *Server) Search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	terms := model.SearchTerms(query.Get("q"))
+	if len(terms) == 0 {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing search query q")
+		return
+	}
+
+	limit := 20
+	if s := query.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > 100 {
+			ErrorResponse(w, http.StatusBadRequest, "invalid_parameter", "limit must be between 1 and 100")
+			return
+		}
+		limit = n
+	}
+
+	types := model.SearchTypes
+	if s := query.Get("types"); s != "" {
+		types = nil
+		for _, t := range strings.Split(s, ",") {
+			t = strings.TrimSpace(t)
+			valid := false
+			for _, known := range model.SearchTypes {
+				valid = valid || t == known
+			}
+			if !valid {
+				ErrorResponse(w, http.StatusBadRequest, "invalid_parameter", "Unknown search type: "+t)
+				return
+			}
+			types = append(types, t)
+		}
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		ErrorResponse(w, http.StatusUnauthorized, "unauthorized", "Missing user in request context")
+		return
+	}
+
+	granted, err := s.Permissions.Granted(userID)
+	if err != nil {
+		log.Errorf("Loading permissions failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not load permissions")
+		return
+	}
+
+	results := []model.SearchResult{}
+	searched := []string{}
+	partial := false
+	for _, t := range types {
+		if perm, ok := searchPermissions[t]; ok && !granted[perm] {
+			continue
+		}
+
+		var hits []model.SearchResult
+		switch t {
+		case model.SearchTypeFirm:
+			hits, err = s.DB.SearchFirms(terms, limit)
+		case model.SearchTypeContact:
+			hits, err = s.DB.SearchContacts(terms, limit)
+		case model.SearchTypeTicket:
+			hits, err = s.DB.SearchTickets(terms, limit)
+		case model.SearchTypeDevice:
+			hits, err = s.PG.SearchDevices(terms, limit)
+			if err != nil {
+				log.Warnf("Device search skipped: %v", err)
+				partial = true
+				continue
+			}
+		}
+		if err != nil {
+			ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not search "+t+"s")
+			return
+		}
+
+		normalizeScores(hits)
+		results = append(results, hits...)
+		searched = append(searched, t)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"query":   strings.Join(terms, " "),
+		"types":   searched,
+		"partial": partial,
+		"results": results,
+		"count":   len(results),
+	})
+}
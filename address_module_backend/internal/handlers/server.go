@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"address_module/internal/middleware"
+	"address_module/internal/notifier"
+	"address_module/internal/token"
+	"address_module/internal/tools"
+)
+
+// Server carries the dependencies shared by all handlers. The database pools
+// are opened once at startup and reused by every request.
+type Server struct {
+	DB          *tools.MySQLDB
+	PG          *tools.PostgresDB
+	Permissions *middleware.Permissions
+	Auth        *middleware.Authenticator
+	Tokens      *token.Keyring
+	Sessions    SessionConfig
+	Account     AccountConfig
+	Accounts    AccountStore // password reset and verification storage; NewServer uses DB
+	Notify      notifier.Config
+}
+
+// NewServer wires the handlers and the permission middleware to the shared pools
+func NewServer(db *tools.MySQLDB, pg *tools.PostgresDB, keys *token.Keyring, notify notifier.Config) *Server {
+	return &Server{
+		DB:          db,
+		PG:          pg,
+		Permissions: middleware.NewPermissions(db, middleware.PermissionConfigFromEnv()),
+		Auth:        middleware.NewAuthenticator(keys, db),
+		Tokens:      keys,
+		Sessions:    SessionConfigFromEnv(),
+		Account:     AccountConfigFromEnv(),
+		Accounts:    db,
+		Notify:      notify,
+	}
+}
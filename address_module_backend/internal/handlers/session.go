@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"address_module/internal/middleware"
+	"address_module/internal/model"
+	"address_module/internal/tools"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SessionConfig holds the token lifetimes read from the environment
+type SessionConfig struct {
+	AccessTTL  time.Duration // ACCESS_TOKEN_TTL, default 2h
+	RefreshTTL time.Duration // REFRESH_TOKEN_TTL, default 720h
+}
+
+// SessionConfigFromEnv reads the token lifetimes, falling back to defaults for unset or invalid values
+func SessionConfigFromEnv() SessionConfig {
+	cfg := SessionConfig{AccessTTL: 2 * time.Hour, RefreshTTL: 30 * 24 * time.Hour}
+	for name, dst := range map[string]*time.Duration{
+		"ACCESS_TOKEN_TTL":  &cfg.AccessTTL,
+		"REFRESH_TOKEN_TTL": &cfg.RefreshTTL,
+	} {
+		v := os.Getenv(name)
+		if v == "" {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Warnf("Invalid %s=%q, using %s", name, v, *dst)
+			continue
+		}
+		*dst = d
+	}
+	return cfg
+}
+
+// randomToken returns n random bytes, hex encoded
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken is how refresh tokens are stored; the plain token is only ever sent to the client
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newTokenPair draws a fresh refresh token and access token ID and returns
+// the refresh token row to store for them
+func (s *Server) newTokenPair(now time.Time) (refresh string, row model.RefreshToken, err error) {
+	refresh, err = randomToken(32)
+	if err != nil {
+		return "", row, err
+	}
+	jti, err := randomToken(16)
+	if err != nil {
+		return "", row, err
+	}
+	row = model.RefreshToken{
+		TokenHash:       hashToken(refresh),
+		AccessJTI:       jti,
+		AccessExpiresAt: now.Add(s.Sessions.AccessTTL),
+		ExpiresAt:       now.Add(s.Sessions.RefreshTTL),
+	}
+	return refresh, row, nil
+}
+
+// loginResponse signs the access token for a stored refresh token row
+func (s *Server) loginResponse(row model.RefreshToken, refresh string, now time.Time) (*model.LoginResponse, error) {
+	token, err := s.generateJWT(row.UserID, row.AccessJTI, row.FamilyID, now, row.AccessExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &model.LoginResponse{
+		Token:        token,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(s.Sessions.AccessTTL.Seconds()),
+	}, nil
+}
+
+// startSession issues the first access and refresh token of a new session
+func (s *Server) startSession(userID int64) (*model.LoginResponse, error) {
+	now := time.Now()
+	refresh, row, err := s.newTokenPair(now)
+	if err != nil {
+		return nil, err
+	}
+	row.UserID = userID
+	if row.FamilyID, err = randomToken(16); err != nil {
+		return nil, err
+	}
+	if _, err := s.DB.InsertRefreshToken(row); err != nil {
+		return nil, err
+	}
+	return s.loginResponse(row, refresh, now)
+}
+
+// RefreshHandler exchanges a refresh token for a new access and refresh token.
+// Each refresh token works once; presenting it again revokes the session.
+func (s *Server) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req model.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	refresh, next, err := s.newTokenPair(now)
+	if err != nil {
+		log.Error("Token generation failed: ", err)
+		http.Error(w, "Could not generate token", http.StatusInternalServerError)
+		return
+	}
+
+	row, err := s.DB.RotateRefreshToken(hashToken(req.RefreshToken), next, now)
+	if errors.Is(err, tools.ErrRefreshTokenInvalid) || errors.Is(err, tools.ErrRefreshTokenReused) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		log.Error("Refresh token rotation failed: ", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Deleted users keep no sessions
+	if _, err := s.DB.GetUserByID(row.UserID, false); err != nil {
+		if err := s.DB.RevokeSession(row.FamilyID, now); err != nil {
+			log.Errorf("Failed to revoke session of deleted user %d: %v", row.UserID, err)
+		}
+		http.Error(w, tools.ErrRefreshTokenInvalid.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	resp, err := s.loginResponse(*row, refresh, now)
+	if err != nil {
+		log.Error("JWT generation failed: ", err)
+		http.Error(w, "Could not generate token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// LogoutHandler revokes the caller's access token and the session it belongs to
+func (s *Server) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := currentUserID(r)
+	token, ok := middleware.TokenFromContext(r.Context())
+	if userID == nil || !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	now := time.Now()
+	if err := s.DB.RevokeAccessToken(token.ID, *userID, token.ExpiresAt); err != nil {
+		log.Errorf("Failed to revoke access token: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not log out")
+		return
+	}
+	if token.SessionID != "" {
+		if err := s.DB.RevokeSession(token.SessionID, now); err != nil {
+			log.Errorf("Failed to revoke session: %v", err)
+			ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not log out")
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Logged out successfully",
+	})
+}
+
+// RevokeUserSessions logs a user out everywhere (?id=): all refresh tokens
+// and every access token issued with them stop working immediately
+func (s *Server) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
+		return
+	}
+
+	userID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "User ID must be a number")
+		return
+	}
+
+	if _, err := s.DB.GetUserByID(userID, true); err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "User not found")
+		return
+	}
+
+	revoked, err := s.DB.RevokeUserSessions(userID, time.Now())
+	if err != nil {
+		log.Errorf("Revoke sessions failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not revoke sessions")
+		return
+	}
+	s.recordAudit(r, model.AuditActionRevokeSessions, model.AuditEntityUser, userID, nil, map[string]int64{"sessions_revoked": revoked})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":          "Sessions revoked successfully",
+		"sessions_revoked": revoked,
+	})
+}
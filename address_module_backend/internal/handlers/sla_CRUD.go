@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"address_module/internal/model"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	log "github.com/sirupsen/logrus"
+)
+
+// AddSLAPolicy creates a new SLA policy
+func (s *Server) AddSLAPolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
+		return
+	}
+
+	var policy model.SLAPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_policy", err.Error())
+		return
+	}
+
+	policyID, err := s.DB.InsertSLAPolicy(policy)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			ErrorResponse(w, http.StatusConflict, "duplicate_entry", "Policy names must be unique and each priority and holiday may appear only once")
+			return
+		}
+
+		log.Errorf("Insert SLA policy failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not insert SLA policy")
+		return
+	}
+
+	policy.ID = policyID
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(policy)
+}
+
+// GetSLAPolicyByID fetches an SLA policy with its targets and holidays
+func (s *Server) GetSLAPolicyByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing policy ID")
+		return
+	}
+
+	policyID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Policy ID must be a number")
+		return
+	}
+
+	policy, err := s.DB.GetSLAPolicyByID(policyID)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "SLA policy not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(policy)
+}
+
+// ListSLAPolicies returns all SLA policies
+func (s *Server) ListSLAPolicies(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	policies, err := s.DB.GetAllSLAPolicies()
+	if err != nil {
+		log.Errorf("GetAllSLAPolicies failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch SLA policies")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"policies": policies,
+		"count":    len(policies),
+	})
+}
+
+// UpdateSLAPolicy replaces an SLA policy definition
+func (s *Server) UpdateSLAPolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only PUT allowed")
+		return
+	}
+
+	var policy model.SLAPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+	if policy.ID == 0 {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "Policy ID is required")
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_policy", err.Error())
+		return
+	}
+
+	if err := s.DB.UpdateSLAPolicy(policy); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "SLA policy not found")
+			return
+		}
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			ErrorResponse(w, http.StatusConflict, "duplicate_entry", "Policy names must be unique and each priority and holiday may appear only once")
+			return
+		}
+
+		log.Errorf("Update SLA policy failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "update_failed", "Could not update SLA policy")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "SLA policy updated successfully",
+	})
+}
+
+// DeleteSLAPolicy removes an SLA policy; firms using it no longer have an SLA
+func (s *Server) DeleteSLAPolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only DELETE allowed")
+		return
+	}
+
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing policy ID")
+		return
+	}
+
+	policyID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Policy ID must be a number")
+		return
+	}
+
+	if err := s.DB.DeleteSLAPolicy(policyID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "SLA policy not found")
+			return
+		}
+		log.Errorf("Delete SLA policy failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not delete SLA policy")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "SLA policy deleted successfully",
+	})
+}
+
+// AssignSLAPolicy sets the SLA policy of a firm; policy_id null removes it.
+// Only tickets created or re-prioritised afterwards get the new deadlines.
+func (s *Server) AssignSLAPolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only PUT allowed")
+		return
+	}
+
+	var assignment model.SLAAssignment
+	if err := json.NewDecoder(r.Body).Decode(&assignment); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+	if assignment.FirmID == 0 {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "firm_id is required")
+		return
+	}
+
+	if err := s.DB.AssignSLAPolicyToFirm(assignment.FirmID, assignment.PolicyID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Firm not found")
+			return
+		}
+		if isForeignKeyError(err) {
+			ErrorResponse(w, http.StatusBadRequest, "invalid_reference", "SLA policy does not exist")
+			return
+		}
+		log.Errorf("Assign SLA policy failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "update_failed", "Could not assign SLA policy")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "SLA policy assigned successfully",
+	})
+}
+
+// GetBreachingTickets lists open tickets that are overdue or will breach their
+// SLA within the next ?within= minutes (default 60)
+func (s *Server) GetBreachingTickets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	within := 60
+	if s := r.URL.Query().Get("within"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			ErrorResponse(w, http.StatusBadRequest, "invalid_parameter", "within must be a non-negative number of minutes")
+			return
+		}
+		within = n
+	}
+
+	now := time.Now()
+	breaches, err := s.DB.GetBreachingTickets(now, now.Add(time.Duration(within)*time.Minute))
+	if err != nil {
+		log.Errorf("GetBreachingTickets failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch breaching tickets")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"within_minutes": within,
+		"tickets":        breaches,
+		"count":          len(breaches),
+	})
+}
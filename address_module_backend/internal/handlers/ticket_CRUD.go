@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"address_module/internal/middleware"
+	"address_module/internal/model"
+	"address_module/internal/notifier"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AddTicket creates a new ticket
+func (s *Server) AddTicket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
+		return
+	}
+
+	var ticket model.Ticket
+	if err := json.NewDecoder(r.Body).Decode(&ticket); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+
+	ticket.Title = strings.TrimSpace(ticket.Title)
+	if ticket.Title == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "Ticket title is required")
+		return
+	}
+	if ticket.Priority == "" {
+		ticket.Priority = model.TicketPriorityNormal
+	}
+	if !model.ValidTicketPriority(ticket.Priority) {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_priority", "Priority must be one of low, normal, high, critical")
+		return
+	}
+
+	if uid, ok := r.Context().Value(middleware.UserIDKey).(int64); ok {
+		ticket.CreatedBy = &uid
+	}
+
+	if !resolveRequester(w, s.DB, &ticket) {
+		return
+	}
+
+	// The starting status always comes from the workflow, never from the client
+	var wf *model.Workflow
+	var err error
+	if ticket.WorkflowID != nil {
+		wf, err = s.DB.GetWorkflowByID(*ticket.WorkflowID)
+		if err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "invalid_workflow", "Workflow not found")
+			return
+		}
+	} else {
+		wf, err = s.DB.GetDefaultWorkflow()
+		if err != nil {
+			log.Errorf("No default workflow configured: %v", err)
+			ErrorResponse(w, http.StatusInternalServerError, "db_error", "No default workflow configured")
+			return
+		}
+	}
+	ticket.Status = wf.InitialStatus
+
+	ticket.FirstResponseDueAt, ticket.ResolutionDueAt, err = s.DB.ComputeTicketDueDates(ticket.FirmaID, ticket.Priority, time.Now())
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not compute SLA due dates")
+		return
+	}
+
+	devices, ok := s.resolveTicketDevices(w, ticket.DeviceIDs)
+	if !ok {
+		return
+	}
+
+	ticketID, err := s.DB.InsertTicketWithDevices(ticket, devices)
+	if err != nil {
+		if isForeignKeyError(err) {
+			ErrorResponse(w, http.StatusBadRequest, "invalid_reference", "Contact, firm, assignee, workflow or queue does not exist")
+			return
+		}
+		log.Errorf("Failed to insert ticket: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not insert ticket")
+		return
+	}
+
+	// Tickets created into an automatic queue without an assignee get one right away
+	if ticket.QueueID != nil && ticket.AssigneeID == nil {
+		if _, err := s.DB.AssignTicket(ticketID, ticket.QueueID, nil); err != nil {
+			log.Errorf("Failed to auto-assign ticket %d: %v", ticketID, err)
+		}
+	}
+
+	created, err := s.DB.GetTicketByID(ticketID)
+	if err != nil {
+		log.Errorf("Failed to reload ticket %d: %v", ticketID, err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not load created ticket")
+		return
+	}
+
+	s.notify(s.DB, func(n *notifier.Notifier) error {
+		if err := n.TicketCreated(*created); err != nil {
+			return err
+		}
+		if created.AssigneeID != nil {
+			return n.TicketAssigned(*created)
+		}
+		return nil
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// GetTicketByID fetches a ticket by ID
+func (s *Server) GetTicketByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing ticket ID")
+		return
+	}
+
+	ticketID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Ticket ID must be a number")
+		return
+	}
+
+	ticket, err := s.DB.GetTicketByID(ticketID)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Ticket not found")
+		return
+	}
+
+	devices, err := s.loadTicketDevices(ticketID)
+	if err != nil {
+		log.Errorf("Failed to load devices for ticket %d: %v", ticketID, err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not load ticket devices")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(model.TicketDetail{Ticket: *ticket, Devices: devices})
+}
+
+// ListTickets returns all tickets
+func (s *Server) ListTickets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	tickets, err := s.DB.GetAllTickets()
+	if err != nil {
+		log.Errorf("GetAllTickets failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch tickets")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tickets": tickets,
+		"count":   len(tickets),
+	})
+}
+
+// UpdateTicket updates an existing ticket
+func (s *Server) UpdateTicket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only PUT allowed")
+		return
+	}
+
+	var ticket model.Ticket
+	if err := json.NewDecoder(r.Body).Decode(&ticket); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+
+	ticket.Title = strings.TrimSpace(ticket.Title)
+	if ticket.ID == 0 || ticket.Title == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "Ticket ID and title are required")
+		return
+	}
+	if !model.ValidTicketPriority(ticket.Priority) {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_priority", "Priority must be one of low, normal, high, critical")
+		return
+	}
+
+	existing, err := s.DB.GetTicketByID(ticket.ID)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Ticket not found")
+		return
+	}
+
+	if !resolveRequester(w, s.DB, &ticket) {
+		return
+	}
+
+	// Devices are checked before anything is written, so an unknown device
+	// leaves the ticket untouched. A nil list keeps the current links.
+	var devices []model.TicketDevice
+	if ticket.DeviceIDs != nil {
+		var ok bool
+		if devices, ok = s.resolveTicketDevices(w, ticket.DeviceIDs); !ok {
+			return
+		}
+	}
+
+	// Deadlines follow the ticket's current priority and firm, counted from creation
+	recompute := ticket.Priority != existing.Priority || !sameID(ticket.FirmaID, existing.FirmaID)
+	if recompute {
+		ticket.FirstResponseDueAt, ticket.ResolutionDueAt, err = s.DB.ComputeTicketDueDates(ticket.FirmaID, ticket.Priority, existing.CreatedAt)
+		if err != nil {
+			log.Errorf("Recomputing SLA due dates failed: %v", err)
+			ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not compute SLA due dates")
+			return
+		}
+	}
+
+	if err := s.DB.UpdateTicketWithDevices(ticket, devices, recompute); err != nil {
+		if isForeignKeyError(err) {
+			ErrorResponse(w, http.StatusBadRequest, "invalid_reference", "Contact, firm or assignee does not exist")
+			return
+		}
+		log.Errorf("Update ticket failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "update_failed", "Ticket could not be updated")
+		return
+	}
+
+	if ticket.AssigneeID != nil && !sameID(ticket.AssigneeID, existing.AssigneeID) {
+		if updated, err := s.DB.GetTicketByID(ticket.ID); err == nil {
+			s.notify(s.DB, func(n *notifier.Notifier) error { return n.TicketAssigned(*updated) })
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Ticket updated successfully",
+	})
+}
+
+// DeleteTicket removes a ticket
+func (s *Server) DeleteTicket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only DELETE allowed")
+		return
+	}
+
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing ticket ID")
+		return
+	}
+
+	ticketID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Ticket ID must be a number")
+		return
+	}
+
+	if err := s.DB.DeleteTicket(ticketID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Ticket not found")
+			return
+		}
+		log.Errorf("Delete ticket failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Ticket could not be deleted")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Ticket deleted successfully",
+	})
+}
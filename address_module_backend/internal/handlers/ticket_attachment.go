@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"address_module/internal/middleware"
+	"address_module/internal/model"
+	"address_module/internal/storage"
+	"address_module/internal/tools"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	errAttachmentTooLarge = errors.New("attachment exceeds the size limit")
+	errAttachmentType     = errors.New("attachment type is not allowed")
+)
+
+// getAttachmentStore returns the blob store or handles the error and response
+func getAttachmentStore(w http.ResponseWriter, cfg storage.Config) (storage.Store, bool) {
+	store, err := storage.NewFSStore(cfg.Path)
+	if err != nil {
+		log.Errorf("Attachment storage unavailable: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "storage_error", "Attachment storage unavailable")
+		return nil, false
+	}
+	return store, true
+}
+
+// cleanFilename keeps only the base name of an uploaded file without control characters
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "." || name == "/" || name == "" {
+		name = "attachment"
+	}
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[len(runes)-255:])
+	}
+	return name
+}
+
+// saveAttachment checks size and sniffed content type, writes the content to
+// blob storage and records it on the ticket. The client's claimed type is ignored.
+func saveAttachment(db *tools.MySQLDB, store storage.Store, cfg storage.Config, a model.TicketAttachment, content io.ReadSeeker) (*model.TicketAttachment, error) {
+	if a.Size > cfg.MaxBytes {
+		return nil, errAttachmentTooLarge
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if !cfg.TypeAllowed(mediaType) {
+		return nil, fmt.Errorf("%w: %s", errAttachmentType, mediaType)
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	sum, size, err := store.Put(io.LimitReader(content, cfg.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > cfg.MaxBytes {
+		// New content (anything already stored is within the limit), so it is safe to drop
+		store.Delete(sum)
+		return nil, errAttachmentTooLarge
+	}
+
+	a.Filename = cleanFilename(a.Filename)
+	a.ContentType = mediaType
+	a.Size = size
+	a.SHA256 = sum
+	id, err := db.InsertTicketAttachment(a)
+	if err != nil {
+		return nil, err
+	}
+	a.ID = id
+	return &a, nil
+}
+
+// UploadTicketAttachment stores one or more files ("file" fields of a multipart
+// form) on a ticket, optionally attached to one of its comments
+func (s *Server) UploadTicketAttachment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		ErrorResponse(w, http.StatusUnauthorized, "unauthorized", "Missing user in request context")
+		return
+	}
+
+	cfg := storage.ConfigFromEnv()
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxBytes+1<<20)
+	if err := r.ParseMultipartForm(8 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			ErrorResponse(w, http.StatusRequestEntityTooLarge, "too_large", fmt.Sprintf("Upload exceeds %d bytes", cfg.MaxBytes))
+			return
+		}
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Expected multipart/form-data")
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	ticketID, err := strconv.ParseInt(r.FormValue("ticket_id"), 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "ticket_id is required")
+		return
+	}
+	var commentID *int64
+	if s := r.FormValue("comment_id"); s != "" {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "invalid_id", "comment_id must be numeric")
+			return
+		}
+		commentID = &id
+	}
+	files := r.MultipartForm.File["file"]
+	if len(files) == 0 {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "At least one file is required")
+		return
+	}
+
+	if _, err := s.DB.GetTicketByID(ticketID); err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Ticket not found")
+		return
+	}
+	if commentID != nil {
+		comment, err := s.DB.GetTicketComment(*commentID)
+		if err != nil || comment.TicketID != ticketID {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Comment not found on this ticket")
+			return
+		}
+		if comment.Internal {
+			allowed, err := s.canSeeInternalNotes(userID)
+			if err != nil {
+				ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not check permissions")
+				return
+			}
+			if !allowed {
+				ErrorResponse(w, http.StatusForbidden, "forbidden", "Attaching to internal notes requires view_internal_notes")
+				return
+			}
+		}
+	}
+
+	store, ok := getAttachmentStore(w, cfg)
+	if !ok {
+		return
+	}
+
+	stored := []model.TicketAttachment{}
+	for _, fh := range files {
+		f, err := fh.Open()
+		if err != nil {
+			ErrorResponse(w, http.StatusBadRequest, "bad_request", "Could not read uploaded file")
+			return
+		}
+		a, err := saveAttachment(s.DB, store, cfg, model.TicketAttachment{
+			TicketID:   ticketID,
+			CommentID:  commentID,
+			Filename:   fh.Filename,
+			Size:       fh.Size,
+			UploadedBy: &userID,
+		}, f)
+		f.Close()
+
+		switch {
+		case errors.Is(err, errAttachmentTooLarge):
+			ErrorResponse(w, http.StatusRequestEntityTooLarge, "too_large", fmt.Sprintf("%s exceeds %d bytes", fh.Filename, cfg.MaxBytes))
+			return
+		case errors.Is(err, errAttachmentType):
+			ErrorResponse(w, http.StatusUnsupportedMediaType, "unsupported_type", fh.Filename+": "+err.Error())
+			return
+		case err != nil:
+			log.Errorf("Storing attachment %s failed: %v", fh.Filename, err)
+			ErrorResponse(w, http.StatusInternalServerError, "storage_error", "Could not store attachment")
+			return
+		}
+		stored = append(stored, *a)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"attachments": stored,
+		"count":       len(stored),
+	})
+}
+
+// ListTicketAttachments lists a ticket's attachments; those on internal notes
+// are only shown to users holding view_internal_notes
+func (s *Server) ListTicketAttachments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		ErrorResponse(w, http.StatusUnauthorized, "unauthorized", "Missing user in request context")
+		return
+	}
+
+	ticketID, err := strconv.ParseInt(r.URL.Query().Get("ticket_id"), 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing or invalid ticket_id")
+		return
+	}
+
+	includeInternal, err := s.canSeeInternalNotes(userID)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not check permissions")
+		return
+	}
+
+	attachments, err := s.DB.GetTicketAttachments(ticketID, includeInternal)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch attachments")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ticket_id":   ticketID,
+		"attachments": attachments,
+		"count":       len(attachments),
+	})
+}
+
+// DownloadTicketAttachment streams an attachment's content
+func (s *Server) DownloadTicketAttachment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		ErrorResponse(w, http.StatusUnauthorized, "unauthorized", "Missing user in request context")
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing or invalid attachment id")
+		return
+	}
+
+	attachment, err := s.DB.GetTicketAttachmentByID(id)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Attachment not found")
+		return
+	}
+	if attachment.Internal {
+		allowed, err := s.canSeeInternalNotes(userID)
+		if err != nil {
+			ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not check permissions")
+			return
+		}
+		if !allowed {
+			// Same answer as for a missing attachment so internal notes are not revealed
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Attachment not found")
+			return
+		}
+	}
+
+	store, ok := getAttachmentStore(w, storage.ConfigFromEnv())
+	if !ok {
+		return
+	}
+	content, err := store.Open(attachment.SHA256)
+	if err != nil {
+		log.Errorf("Blob %s of attachment %d unavailable: %v", attachment.SHA256, id, err)
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Attachment content missing")
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, content); err != nil {
+		log.Warnf("Download of attachment %d interrupted: %v", id, err)
+	}
+}
+
+// saveInboundAttachments stores the attachments of an ingested email. Files that
+// break the limits are skipped; they stay listed in the ticket or comment text.
+func saveInboundAttachments(db *tools.MySQLDB, ticketID int64, commentID *int64, attachments []model.InboundAttachment) {
+	if len(attachments) == 0 {
+		return
+	}
+	cfg := storage.ConfigFromEnv()
+	store, err := storage.NewFSStore(cfg.Path)
+	if err != nil {
+		log.Errorf("Attachment storage unavailable, inbound attachments not stored: %v", err)
+		return
+	}
+
+	for _, in := range attachments {
+		_, err := saveAttachment(db, store, cfg, model.TicketAttachment{
+			TicketID:  ticketID,
+			CommentID: commentID,
+			Filename:  in.Filename,
+			Size:      int64(in.Size),
+		}, bytes.NewReader(in.Data))
+		if err != nil {
+			log.WithFields(log.Fields{"ticket_id": ticketID, "filename": in.Filename}).Warnf("Inbound attachment not stored: %v", err)
+		}
+	}
+}
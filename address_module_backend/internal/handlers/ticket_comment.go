@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"address_module/internal/middleware"
+	"address_module/internal/model"
+	"address_module/internal/notifier"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// canSeeInternalNotes reports whether the user may read and write internal notes,
+// using the cached permissions
+func (s *Server) canSeeInternalNotes(userID int64) (bool, error) {
+	granted, err := s.Permissions.Granted(userID)
+	return granted["view_internal_notes"], err
+}
+
+// AddTicketComment posts a public reply or an internal note on a ticket
+func (s *Server) AddTicketComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		ErrorResponse(w, http.StatusUnauthorized, "unauthorized", "Missing user in request context")
+		return
+	}
+
+	var comment model.TicketComment
+	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+
+	comment.Body = strings.TrimSpace(comment.Body)
+	if comment.TicketID == 0 || comment.Body == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "ticket_id and body are required")
+		return
+	}
+	// The author is whoever is logged in, regardless of what the body claims.
+	// Contact authorship only comes from inbound mail.
+	comment.ID = 0
+	comment.AuthorID = &userID
+	comment.AuthorContactID = nil
+	comment.CreatedAt = time.Time{}
+
+	if comment.Internal {
+		allowed, err := s.canSeeInternalNotes(userID)
+		if err != nil {
+			ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not check permissions")
+			return
+		}
+		if !allowed {
+			ErrorResponse(w, http.StatusForbidden, "forbidden", "Writing internal notes requires view_internal_notes")
+			return
+		}
+	}
+
+	ticket, err := s.DB.GetTicketByID(comment.TicketID)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Ticket not found")
+		return
+	}
+
+	stored, err := s.DB.InsertTicketComment(comment)
+	if err != nil {
+		log.Errorf("Failed to insert ticket comment: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not save comment")
+		return
+	}
+
+	// Only a public reply counts as the first response towards the SLA
+	if !stored.Internal {
+		if err := s.DB.MarkFirstResponse(stored.TicketID, stored.CreatedAt); err != nil {
+			log.Errorf("Failed to record first response on ticket %d: %v", stored.TicketID, err)
+		}
+	}
+
+	s.notify(s.DB, func(n *notifier.Notifier) error { return n.TicketCommented(*ticket, *stored) })
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(stored)
+}
+
+// GetTicketComments returns a ticket's conversation; internal notes are
+// filtered out unless the user holds view_internal_notes
+func (s *Server) GetTicketComments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	userID, ok := r.Context().Value(middleware.UserIDKey).(int64)
+	if !ok {
+		ErrorResponse(w, http.StatusUnauthorized, "unauthorized", "Missing user in request context")
+		return
+	}
+
+	idStr := r.URL.Query().Get("ticket_id")
+	if idStr == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing ticket_id")
+		return
+	}
+
+	ticketID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "ticket_id must be numeric")
+		return
+	}
+
+	includeInternal, err := s.canSeeInternalNotes(userID)
+	if err != nil {
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not check permissions")
+		return
+	}
+
+	comments, err := s.DB.GetTicketComments(ticketID, includeInternal)
+	if err != nil {
+		log.Errorf("GetTicketComments failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch comments")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"ticket_id": ticketID,
+		"comments":  comments,
+		"count":     len(comments),
+	})
+}
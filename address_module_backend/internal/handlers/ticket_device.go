@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"address_module/internal/model"
+	"address_module/internal/tools"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// resolveTicketDevices checks that every device exists in the device database
+// and returns the snapshots to store with the ticket link
+func (s *Server) resolveTicketDevices(w http.ResponseWriter, deviceIDs []int64) ([]model.TicketDevice, bool) {
+	devices := []model.TicketDevice{}
+	if len(deviceIDs) == 0 {
+		return devices, true
+	}
+
+	seen := make(map[int64]bool)
+	for _, id := range deviceIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		device, err := s.PG.GetDeviceByID(id, false)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				ErrorResponse(w, http.StatusBadRequest, "unknown_device", fmt.Sprintf("Device %d does not exist", id))
+				return nil, false
+			}
+			log.Errorf("GetDeviceByID(%d) failed: %v", id, err)
+			ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not look up devices")
+			return nil, false
+		}
+		devices = append(devices, tools.TicketDeviceSnapshot(device))
+	}
+	return devices, true
+}
+
+// loadTicketDevices returns the devices linked to a ticket, refreshed from the
+// device database where possible. If a device lookup fails, or a device
+// vanished without going through DeleteDevice, the stored snapshot is used.
+func (s *Server) loadTicketDevices(ticketID int64) ([]model.TicketDevice, error) {
+	devices, err := s.DB.GetTicketDevices(ticketID)
+	if err != nil || len(devices) == 0 {
+		return devices, err
+	}
+
+	for i, d := range devices {
+		if d.Deleted {
+			continue
+		}
+		device, err := s.PG.GetDeviceByID(d.DeviceID, false)
+		if errors.Is(err, sql.ErrNoRows) {
+			devices[i].Deleted = true
+			if err := s.DB.MarkDeviceDeleted(d); err != nil {
+				log.Warnf("Could not flag missing device %d on ticket links: %v", d.DeviceID, err)
+			}
+			continue
+		}
+		if err != nil {
+			log.Warnf("GetDeviceByID(%d) failed, using snapshot: %v", d.DeviceID, err)
+			continue
+		}
+		devices[i] = tools.TicketDeviceSnapshot(device)
+	}
+	return devices, nil
+}
+
+// GetTicketsByDevice returns the ticket history of a device
+func (s *Server) GetTicketsByDevice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	idStr := r.URL.Query().Get("device_id")
+	if idStr == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing device_id")
+		return
+	}
+
+	deviceID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "device_id must be numeric")
+		return
+	}
+
+	tickets, err := s.DB.GetTicketsByDeviceID(deviceID)
+	if err != nil {
+		log.Errorf("GetTicketsByDeviceID failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch device tickets")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"device_id": deviceID,
+		"tickets":   tickets,
+		"count":     len(tickets),
+	})
+}
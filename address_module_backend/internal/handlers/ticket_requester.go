@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"address_module/internal/model"
+	"address_module/internal/tools"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// resolveRequester fills in contact_id from contact_email and firma_id from the
+// contact's firms when they are not given explicitly
+func resolveRequester(w http.ResponseWriter, db *tools.MySQLDB, ticket *model.Ticket) bool {
+	if ticket.ContactID == nil && ticket.ContactEmail != "" {
+		contactID, err := db.GetContactIDByEmail(ticket.ContactEmail)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				ErrorResponse(w, http.StatusBadRequest, "unknown_contact", "No contact with email "+ticket.ContactEmail)
+				return false
+			}
+			log.Errorf("Contact lookup by email failed: %v", err)
+			ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not resolve contact")
+			return false
+		}
+		ticket.ContactID = &contactID
+	}
+
+	if ticket.ContactID != nil && ticket.FirmaID == nil {
+		firmID, err := db.GetPrimaryFirmForContact(*ticket.ContactID)
+		if err != nil {
+			ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not resolve firm of contact")
+			return false
+		}
+		ticket.FirmaID = firmID
+	}
+	return true
+}
+
+// GetTicketsByFirm returns all tickets of a firm
+func (s *Server) GetTicketsByFirm(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	idStr := r.URL.Query().Get("firm_id")
+	if idStr == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing firm_id")
+		return
+	}
+
+	firmID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "firm_id must be numeric")
+		return
+	}
+
+	tickets, err := s.DB.GetTicketsByFirmID(firmID)
+	if err != nil {
+		log.Errorf("GetTicketsByFirmID failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch firm tickets")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"firm_id": firmID,
+		"tickets": tickets,
+		"count":   len(tickets),
+	})
+}
+
+// GetTicketsByContact returns all tickets requested by a contact
+func (s *Server) GetTicketsByContact(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	idStr := r.URL.Query().Get("contact_id")
+	if idStr == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing contact_id")
+		return
+	}
+
+	contactID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "contact_id must be numeric")
+		return
+	}
+
+	tickets, err := s.DB.GetTicketsByContactID(contactID)
+	if err != nil {
+		log.Errorf("GetTicketsByContactID failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch contact tickets")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"contact_id": contactID,
+		"tickets":    tickets,
+		"count":      len(tickets),
+	})
+}
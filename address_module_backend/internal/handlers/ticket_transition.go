@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"address_module/internal/middleware"
+	"address_module/internal/model"
+	"address_module/internal/notifier"
+	"address_module/internal/tools"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// TransitionTicket moves a ticket to a new status according to its workflow
+func (s *Server) TransitionTicket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
+		return
+	}
+
+	var req model.TicketTransitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+
+	req.ToStatus = strings.TrimSpace(req.ToStatus)
+	if req.TicketID == 0 || req.ToStatus == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "ticket_id and to_status are required")
+		return
+	}
+
+	var userID *int64
+	if uid, ok := r.Context().Value(middleware.UserIDKey).(int64); ok {
+		userID = &uid
+	}
+
+	transition, err := s.DB.TransitionTicket(req.TicketID, req.ToStatus, userID, req.Comment)
+	if err != nil {
+		var transitionErr *tools.TransitionError
+		switch {
+		case errors.As(err, &transitionErr):
+			ErrorResponse(w, http.StatusConflict, "invalid_transition", transitionErr.Error())
+		case errors.Is(err, sql.ErrNoRows):
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Ticket not found")
+		default:
+			log.Errorf("Ticket transition failed: %v", err)
+			ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not change ticket status")
+		}
+		return
+	}
+
+	if transition.ToStatus == model.TicketStatusResolved {
+		if ticket, err := s.DB.GetTicketByID(transition.TicketID); err == nil {
+			s.notify(s.DB, func(n *notifier.Notifier) error { return n.TicketResolved(*ticket) })
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transition)
+}
+
+// GetTicketTransitions returns the status history of a ticket
+func (s *Server) GetTicketTransitions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	idStr := r.URL.Query().Get("ticket_id")
+	if idStr == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing ticket_id")
+		return
+	}
+
+	ticketID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "ticket_id must be numeric")
+		return
+	}
+
+	transitions, err := s.DB.GetTicketTransitions(ticketID)
+	if err != nil {
+		log.Errorf("GetTicketTransitions failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch ticket history")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transitions)
+}
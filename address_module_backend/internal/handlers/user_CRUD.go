@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"address_module/internal/model"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-sql-driver/mysql"
 	log "github.com/sirupsen/logrus"
@@ -13,7 +15,7 @@ import (
 )
 
 // AddUser creates a new user
-func AddUser(w http.ResponseWriter, r *http.Request) {
+func (s *Server) AddUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
 		return
@@ -31,14 +33,11 @@ func AddUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	user.HashedPassword = string(hashedPassword)
+	// Accounts created by an administrator do not go through email verification
+	verifiedAt := time.Now()
+	user.EmailVerifiedAt = &verifiedAt
 
-	db, ok := getDBInstance(w)
-	if !ok {
-		return
-	}
-	defer db.Close()
-
-	userID, err := db.InsertUser(user)
+	userID, err := s.DB.InsertUser(user)
 	if err != nil {
 		var mysqlErr *mysql.MySQLError
 		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
@@ -52,12 +51,14 @@ func AddUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	user.ID = userID
+	s.recordAudit(r, model.AuditActionCreate, model.AuditEntityUser, userID, nil, user)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
 
 // GetUserByID fetches a user by ID
-func GetUserByID(w http.ResponseWriter, r *http.Request) {
+func (s *Server) GetUserByID(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
 		return
@@ -74,14 +75,12 @@ func GetUserByID(w http.ResponseWriter, r *http.Request) {
 		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "User ID must be a number")
 		return
 	}
-
-	db, ok := getDBInstance(w)
+	include, ok := s.includeDeleted(w, r)
 	if !ok {
 		return
 	}
-	defer db.Close()
 
-	user, err := db.GetUserByID(userID)
+	user, err := s.DB.GetUserByID(userID, include)
 	if err != nil {
 		ErrorResponse(w, http.StatusNotFound, "not_found", "User not found")
 		return
@@ -92,7 +91,7 @@ func GetUserByID(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateUser updates an existing user
-func UpdateUser(w http.ResponseWriter, r *http.Request) {
+func (s *Server) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPut {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only PUT allowed")
 		return
@@ -104,17 +103,19 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, ok := getDBInstance(w)
-	if !ok {
+	before, err := s.DB.GetUserByID(user.ID, false)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "User not found")
 		return
 	}
-	defer db.Close()
 
-	if err := db.UpdateUser(user); err != nil {
+	if err := s.DB.UpdateUser(user); err != nil {
 		log.Errorf("Update failed: %v", err)
 		ErrorResponse(w, http.StatusInternalServerError, "update_failed", "User could not be updated")
 		return
 	}
+	user.CreatedAt = before.CreatedAt // not changed by UpdateUser
+	s.recordAudit(r, model.AuditActionUpdate, model.AuditEntityUser, user.ID, before, user)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -122,8 +123,8 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// DeleteUser removes a user
-func DeleteUser(w http.ResponseWriter, r *http.Request) {
+// DeleteUser soft-deletes a user, who can then no longer log in
+func (s *Server) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only DELETE allowed")
 		return
@@ -141,19 +142,60 @@ func DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, ok := getDBInstance(w)
-	if !ok {
+	user, err := s.DB.GetUserByID(userID, false)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "User not found")
 		return
 	}
-	defer db.Close()
 
-	if err := db.DeleteUser(userID); err != nil {
-		ErrorResponse(w, http.StatusNotFound, "not_found", "User not found")
+	if err := s.DB.SoftDeleteUser(userID, currentUserID(r)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "User not found")
+			return
+		}
+		log.Errorf("Delete user failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "User could not be deleted")
 		return
 	}
+	s.recordAudit(r, model.AuditActionDelete, model.AuditEntityUser, userID, user, nil)
+	s.Permissions.Invalidate(userID)
+	if _, err := s.DB.RevokeUserSessions(userID, time.Now()); err != nil {
+		log.Errorf("Failed to revoke sessions of deleted user %d: %v", userID, err)
+	}
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "User deleted successfully",
 	})
 }
+
+// RestoreUser brings back a soft-deleted user (?id=)
+func (s *Server) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
+		return
+	}
+
+	userID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "User ID must be a number")
+		return
+	}
+
+	if err := s.DB.RestoreUser(userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "No deleted user with this ID")
+			return
+		}
+		log.Errorf("Restore user failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "User could not be restored")
+		return
+	}
+	s.recordAudit(r, model.AuditActionRestore, model.AuditEntityUser, userID, nil, nil)
+	s.Permissions.Invalidate(userID)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "User restored successfully",
+	})
+}
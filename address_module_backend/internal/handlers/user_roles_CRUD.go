@@ -10,7 +10,7 @@ import (
 )
 
 // AssignUserRole assigns a role to a user
-func AssignUserRole(w http.ResponseWriter, r *http.Request) {
+func (s *Server) AssignUserRole(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
 		return
@@ -22,17 +22,13 @@ func AssignUserRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, ok := getDBInstance(w)
-	if !ok {
-		return
-	}
-	defer db.Close()
-
-	if err := db.InsertUserRole(ur); err != nil {
+	if err := s.DB.InsertUserRole(ur); err != nil {
 		log.Errorf("Failed to assign user-role: %v", err)
 		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not assign role to user")
 		return
 	}
+	s.recordAudit(r, model.AuditActionCreate, model.AuditEntityUserRole, ur.UserID, nil, ur)
+	s.Permissions.Invalidate(ur.UserID)
 
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -41,7 +37,7 @@ func AssignUserRole(w http.ResponseWriter, r *http.Request) {
 }
 
 // RemoveUserRole deletes a user-role mapping
-func RemoveUserRole(w http.ResponseWriter, r *http.Request) {
+func (s *Server) RemoveUserRole(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodDelete {
 		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only DELETE allowed")
 		return
@@ -58,17 +54,13 @@ func RemoveUserRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, ok := getDBInstance(w)
-	if !ok {
-		return
-	}
-	defer db.Close()
-
-	if err := db.DeleteUserRole(userID, roleID); err != nil {
+	if err := s.DB.DeleteUserRole(userID, roleID); err != nil {
 		log.Errorf("Failed to remove user-role: %v", err)
 		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not remove user-role mapping")
 		return
 	}
+	s.recordAudit(r, model.AuditActionDelete, model.AuditEntityUserRole, userID, model.UserRole{UserID: userID, RoleID: roleID}, nil)
+	s.Permissions.Invalidate(userID)
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
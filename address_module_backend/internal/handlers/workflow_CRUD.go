@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"address_module/internal/model"
+	"address_module/internal/tools"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+	log "github.com/sirupsen/logrus"
+)
+
+// AddWorkflow creates a new ticket workflow
+func (s *Server) AddWorkflow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only POST allowed")
+		return
+	}
+
+	var wf model.Workflow
+	if err := json.NewDecoder(r.Body).Decode(&wf); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+	if err := wf.Validate(); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_workflow", err.Error())
+		return
+	}
+
+	workflowID, err := s.DB.InsertWorkflow(wf)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			ErrorResponse(w, http.StatusConflict, "duplicate_entry", "Workflow name must be unique")
+			return
+		}
+
+		log.Errorf("Insert workflow failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not insert workflow")
+		return
+	}
+
+	wf.ID = workflowID
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(wf)
+}
+
+// GetWorkflowByID fetches a workflow with its transitions
+func (s *Server) GetWorkflowByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing workflow ID")
+		return
+	}
+
+	workflowID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Workflow ID must be a number")
+		return
+	}
+
+	wf, err := s.DB.GetWorkflowByID(workflowID)
+	if err != nil {
+		ErrorResponse(w, http.StatusNotFound, "not_found", "Workflow not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wf)
+}
+
+// ListWorkflows returns all workflows
+func (s *Server) ListWorkflows(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only GET allowed")
+		return
+	}
+
+	workflows, err := s.DB.GetAllWorkflows()
+	if err != nil {
+		log.Errorf("GetAllWorkflows failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not fetch workflows")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"workflows": workflows,
+		"count":     len(workflows),
+	})
+}
+
+// UpdateWorkflow replaces a workflow definition
+func (s *Server) UpdateWorkflow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only PUT allowed")
+		return
+	}
+
+	var wf model.Workflow
+	if err := json.NewDecoder(r.Body).Decode(&wf); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "bad_request", "Invalid JSON input")
+		return
+	}
+	if wf.ID == 0 {
+		ErrorResponse(w, http.StatusBadRequest, "missing_fields", "Workflow ID is required")
+		return
+	}
+	if err := wf.Validate(); err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_workflow", err.Error())
+		return
+	}
+
+	if err := s.DB.UpdateWorkflow(wf); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Workflow not found")
+			return
+		}
+		if errors.Is(err, tools.ErrNoDefaultWorkflow) {
+			ErrorResponse(w, http.StatusConflict, "default_workflow", "Make another workflow the default before unsetting is_default")
+			return
+		}
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			ErrorResponse(w, http.StatusConflict, "duplicate_entry", "Workflow name must be unique")
+			return
+		}
+
+		log.Errorf("Update workflow failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "update_failed", "Could not update workflow")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Workflow updated successfully",
+	})
+}
+
+// DeleteWorkflow removes a workflow
+func (s *Server) DeleteWorkflow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		ErrorResponse(w, http.StatusMethodNotAllowed, "invalid_method", "Only DELETE allowed")
+		return
+	}
+
+	idStr := r.URL.Query().Get("id")
+	if idStr == "" {
+		ErrorResponse(w, http.StatusBadRequest, "missing_parameter", "Missing workflow ID")
+		return
+	}
+
+	workflowID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		ErrorResponse(w, http.StatusBadRequest, "invalid_id", "Workflow ID must be a number")
+		return
+	}
+
+	if err := s.DB.DeleteWorkflow(workflowID); err != nil {
+		if errors.Is(err, tools.ErrDefaultWorkflow) {
+			ErrorResponse(w, http.StatusConflict, "default_workflow", "The default workflow cannot be deleted")
+			return
+		}
+		if errors.Is(err, tools.ErrWorkflowStatusesInUse) {
+			ErrorResponse(w, http.StatusConflict, "workflow_in_use", err.Error())
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			ErrorResponse(w, http.StatusNotFound, "not_found", "Workflow not found")
+			return
+		}
+		log.Errorf("Delete workflow failed: %v", err)
+		ErrorResponse(w, http.StatusInternalServerError, "db_error", "Could not delete workflow")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Workflow deleted successfully",
+	})
+}
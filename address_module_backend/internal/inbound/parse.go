@@ -0,0 +1,208 @@
+package inbound
+
+import (
+	"address_module/internal/model"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrNoSender is returned for messages without a usable From header
+var ErrNoSender = errors.New("message has no valid From address")
+
+// maxPartDepth bounds multipart nesting so a crafted mail cannot recurse forever
+const maxPartDepth = 10
+
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// Parse reads a raw RFC 5322 message and extracts sender, subject, text body and attachments
+func Parse(r io.Reader) (*model.InboundMail, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid message: %w", err)
+	}
+
+	from, err := (&mail.AddressParser{WordDecoder: wordDecoder}).Parse(msg.Header.Get("From"))
+	if err != nil {
+		return nil, ErrNoSender
+	}
+
+	subject, err := wordDecoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	parsed := &model.InboundMail{
+		MessageID:   strings.TrimSpace(msg.Header.Get("Message-Id")),
+		From:        strings.ToLower(from.Address),
+		FromName:    from.Name,
+		Subject:     strings.TrimSpace(subject),
+		Attachments: []model.InboundAttachment{},
+	}
+	if parsed.MessageID == "" {
+		// Without a Message-ID, the content itself identifies a redelivery
+		sum := sha256.Sum256(raw)
+		parsed.MessageID = "<sha256-" + hex.EncodeToString(sum[:]) + ">"
+	}
+
+	var text, htmlText string
+	if err := walkPart(mail.Header(msg.Header), msg.Body, 0, parsed, &text, &htmlText); err != nil {
+		return nil, err
+	}
+	if text == "" && htmlText != "" {
+		text = htmlToText(htmlText)
+	}
+	parsed.Body = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	return parsed, nil
+}
+
+type partHeader interface {
+	Get(key string) string
+}
+
+func walkPart(h partHeader, body io.Reader, depth int, out *model.InboundMail, text, htmlText *string) error {
+	if depth > maxPartDepth {
+		return errors.New("multipart nesting too deep")
+	}
+
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{"charset": "us-ascii"}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("invalid multipart body: %w", err)
+			}
+			if err := walkPart(p.Header, p, depth+1, out, text, htmlText); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransfer(h.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("could not decode part: %w", err)
+	}
+
+	disposition, dparams, _ := mime.ParseMediaType(h.Get("Content-Disposition"))
+	filename := dparams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	if decoded, err := wordDecoder.DecodeHeader(filename); err == nil {
+		filename = decoded
+	}
+
+	isAttachment := disposition == "attachment" || filename != ""
+	switch {
+	case !isAttachment && mediaType == "text/plain" && *text == "":
+		*text = toUTF8(data, params["charset"])
+	case !isAttachment && mediaType == "text/html" && *htmlText == "":
+		*htmlText = toUTF8(data, params["charset"])
+	case isAttachment || !strings.HasPrefix(mediaType, "text/"):
+		if filename == "" {
+			filename = "attachment"
+		}
+		out.Attachments = append(out.Attachments, model.InboundAttachment{
+			Filename:    filename,
+			ContentType: mediaType,
+			Size:        len(data),
+			Data:        data,
+		})
+	}
+	return nil
+}
+
+func decodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+// newlineStripper drops CR/LF so base64 line breaks do not trip the decoder
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (n *newlineStripper) Read(p []byte) (int, error) {
+	for {
+		c, err := n.r.Read(p)
+		j := 0
+		for _, b := range p[:c] {
+			if b != '\r' && b != '\n' && b != ' ' && b != '\t' {
+				p[j] = b
+				j++
+			}
+		}
+		if j > 0 || err != nil {
+			return j, err
+		}
+	}
+}
+
+// charsetReader supports the charsets we actually receive besides UTF-8
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(toUTF8(data, charset)), nil
+}
+
+func toUTF8(data []byte, charset string) string {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "iso-8859-15", "latin1", "windows-1252", "cp1252":
+		// Close enough for German mail: map each byte to the code point of the same value
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return strings.ToValidUTF8(string(data), "�")
+}
+
+var (
+	htmlBreaks = regexp.MustCompile(`(?i)<\s*(br|/p|/div|/li|/tr|/h[1-6])[^>]*>`)
+	htmlTags   = regexp.MustCompile(`(?s)<[^>]*>`)
+	htmlDrop   = regexp.MustCompile(`(?is)<(script|style|head)[^>]*>.*?</(script|style|head)>`)
+	blankLines = regexp.MustCompile(`\n\s*\n\s*\n+`)
+)
+
+func htmlToText(s string) string {
+	s = htmlDrop.ReplaceAllString(s, "")
+	s = htmlBreaks.ReplaceAllString(s, "\n")
+	s = htmlTags.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	return blankLines.ReplaceAllString(s, "\n\n")
+}
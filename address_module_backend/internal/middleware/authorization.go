@@ -1,21 +1,62 @@
 package middleware
 
 import (
+	"address_module/internal/token"
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	log "github.com/sirupsen/logrus"
 )
 
-var jwtSecret = []byte("super_secret_change_me")
-
 type contextKey string
 
 const UserIDKey contextKey = "user_id"
 
-// Authorization middleware checks for a valid JWT and extracts the user ID
-func Authorization(next http.Handler) http.Handler {
+const tokenPermissionsKey contextKey = "token_permissions"
+
+const tokenKey contextKey = "token"
+
+// Token identifies the access token a request was authorized with
+type Token struct {
+	ID        string    // "jti"
+	SessionID string    // "sid", the refresh token family the token was issued with
+	ExpiresAt time.Time // "exp"
+}
+
+// TokenFromContext returns the access token of a request that passed Authorization
+func TokenFromContext(ctx context.Context) (Token, bool) {
+	t, ok := ctx.Value(tokenKey).(Token)
+	return t, ok
+}
+
+// RevocationStore reports whether an access token was revoked before it expired
+type RevocationStore interface {
+	IsTokenRevoked(jti string) (bool, error)
+}
+
+// Authenticator verifies access tokens with the keyring and checks them against the revocation list
+type Authenticator struct {
+	keys    *token.Keyring
+	revoked RevocationStore
+}
+
+// NewAuthenticator returns an Authenticator verifying with keys and backed by store
+func NewAuthenticator(keys *token.Keyring, store RevocationStore) *Authenticator {
+	return &Authenticator{keys: keys, revoked: store}
+}
+
+// tokenPermissions is the optional permission set ("perms") and version
+// stamp ("pv") embedded in a token
+type tokenPermissions struct {
+	names   map[string]bool
+	version int64
+}
+
+// Authorization middleware checks for a valid, unrevoked JWT and extracts the user ID
+func (a *Authenticator) Authorization(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
@@ -25,33 +66,65 @@ func Authorization(next http.Handler) http.Handler {
 
 		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
 
-		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			// Validate signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return jwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := a.keys.Parse(tokenStr)
+		if err != nil {
 			http.Error(w, "Invalid token", http.StatusUnauthorized)
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok || !token.Valid {
-			http.Error(w, "Invalid token claims", http.StatusUnauthorized)
-			return
-		}
-
 		userIDFloat, ok := claims["user_id"].(float64)
 		if !ok {
 			http.Error(w, "Invalid user_id in token", http.StatusUnauthorized)
 			return
 		}
 
+		jti, _ := claims["jti"].(string)
+		if jti == "" {
+			http.Error(w, "Token has no jti, log in again", http.StatusUnauthorized)
+			return
+		}
+		revoked, err := a.revoked.IsTokenRevoked(jti)
+		if err != nil {
+			log.Errorf("Failed to check token revocation: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		if revoked {
+			http.Error(w, "Token has been revoked", http.StatusUnauthorized)
+			return
+		}
+
+		tok := Token{ID: jti}
+		tok.SessionID, _ = claims["sid"].(string)
+		if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+			tok.ExpiresAt = exp.Time
+		}
+
 		// Inject user_id into request context
 		ctx := context.WithValue(r.Context(), UserIDKey, int64(userIDFloat))
+		ctx = context.WithValue(ctx, tokenKey, tok)
+		if tp, ok := parseTokenPermissions(claims); ok {
+			ctx = context.WithValue(ctx, tokenPermissionsKey, tp)
+		}
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// parseTokenPermissions reads the permission claims if the token has them
+func parseTokenPermissions(claims jwt.MapClaims) (tokenPermissions, bool) {
+	version, ok := claims["pv"].(float64)
+	if !ok {
+		return tokenPermissions{}, false
+	}
+	list, ok := claims["perms"].([]interface{})
+	if !ok {
+		return tokenPermissions{}, false
+	}
+	names := make(map[string]bool, len(list))
+	for _, v := range list {
+		if name, ok := v.(string); ok {
+			names[name] = true
+		}
+	}
+	return tokenPermissions{names: names, version: int64(version)}, true
+}
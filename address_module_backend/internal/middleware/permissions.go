@@ -1,15 +1,169 @@
 package middleware
 
 import (
-	"address_module/internal/tools"
+	"address_module/internal/model"
 	"net/http"
+	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 )
 
-// RequirePermission enforces that the logged-in user has a specific permission
-func RequirePermission(requiredPermission string) func(http.Handler) http.Handler {
+// PermissionStore loads the permissions granted to a user through their roles
+// and the version counter that changes whenever any grant changes
+type PermissionStore interface {
+	GetUserPermissions(userID int64) ([]model.Permission, error)
+	GetPermissionVersion() (int64, error)
+	BumpPermissionVersion() (int64, error)
+}
+
+// PermissionConfig controls permission caching and the permission claims in tokens
+type PermissionConfig struct {
+	// CacheTTL is how long a user's permissions are reused; 0 disables the cache
+	CacheTTL time.Duration
+	// EmbedInToken adds the permission set and version stamp to issued tokens
+	EmbedInToken bool
+}
+
+// PermissionConfigFromEnv reads PERMISSION_CACHE_TTL and JWT_EMBED_PERMISSIONS
+func PermissionConfigFromEnv() PermissionConfig {
+	cfg := PermissionConfig{CacheTTL: time.Minute}
+	if v := os.Getenv("PERMISSION_CACHE_TTL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			cfg.CacheTTL = d
+		} else {
+			log.Warnf("Invalid PERMISSION_CACHE_TTL=%q, using %s", v, cfg.CacheTTL)
+		}
+	}
+	if v := os.Getenv("JWT_EMBED_PERMISSIONS"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			cfg.EmbedInToken = b
+		} else {
+			log.Warnf("Invalid JWT_EMBED_PERMISSIONS=%q, using false", v)
+		}
+	}
+	return cfg
+}
+
+type cachedPermissions struct {
+	names   map[string]bool
+	expires time.Time
+}
+
+// Permissions checks permissions against a shared store. Lookups are cached
+// per user for CacheTTL and dropped by Invalidate / InvalidateAll.
+type Permissions struct {
+	store PermissionStore
+	cfg   PermissionConfig
+
+	mu             sync.Mutex
+	users          map[int64]cachedPermissions
+	version        int64
+	versionExpires time.Time
+}
+
+// NewPermissions returns a checker backed by store
+func NewPermissions(store PermissionStore, cfg PermissionConfig) *Permissions {
+	return &Permissions{
+		store: store,
+		cfg:   cfg,
+		users: make(map[int64]cachedPermissions),
+	}
+}
+
+// EmbedInToken reports whether issued tokens should carry the permission claims
+func (p *Permissions) EmbedInToken() bool {
+	return p.cfg.EmbedInToken
+}
+
+// Granted returns the names of the permissions the user currently holds
+func (p *Permissions) Granted(userID int64) (map[string]bool, error) {
+	now := time.Now()
+	p.mu.Lock()
+	if c, ok := p.users[userID]; ok && now.Before(c.expires) {
+		p.mu.Unlock()
+		return c.names, nil
+	}
+	p.mu.Unlock()
+
+	perms, err := p.store.GetUserPermissions(userID)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(perms))
+	for _, perm := range perms {
+		names[perm.Name] = true
+	}
+
+	if p.cfg.CacheTTL > 0 {
+		p.mu.Lock()
+		p.users[userID] = cachedPermissions{names: names, expires: now.Add(p.cfg.CacheTTL)}
+		p.mu.Unlock()
+	}
+	return names, nil
+}
+
+// Version returns the current grant version, cached like the permission sets
+func (p *Permissions) Version() (int64, error) {
+	now := time.Now()
+	p.mu.Lock()
+	if p.version > 0 && now.Before(p.versionExpires) {
+		v := p.version
+		p.mu.Unlock()
+		return v, nil
+	}
+	p.mu.Unlock()
+
+	v, err := p.store.GetPermissionVersion()
+	if err != nil {
+		return 0, err
+	}
+	p.setVersion(v, now)
+	return v, nil
+}
+
+func (p *Permissions) setVersion(v int64, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.version = v
+	p.versionExpires = now.Add(p.cfg.CacheTTL)
+}
+
+// Invalidate drops the cached permissions of one user after their roles
+// changed. Tokens issued before the change no longer count as current.
+func (p *Permissions) Invalidate(userID int64) {
+	p.mu.Lock()
+	delete(p.users, userID)
+	p.mu.Unlock()
+	p.bumpVersion()
+}
+
+// InvalidateAll drops every cached permission set after a role or
+// permission changed in a way that can affect many users
+func (p *Permissions) InvalidateAll() {
+	p.mu.Lock()
+	p.users = make(map[int64]cachedPermissions)
+	p.mu.Unlock()
+	p.bumpVersion()
+}
+
+func (p *Permissions) bumpVersion() {
+	v, err := p.store.BumpPermissionVersion()
+	if err != nil {
+		// Forget the cached version so the next check re-reads it
+		log.Errorf("Failed to bump permission version: %v", err)
+		p.setVersion(0, time.Now())
+		return
+	}
+	p.setVersion(v, time.Now())
+}
+
+// Require enforces that the logged-in user has a specific permission. A
+// permission set embedded in the token is trusted only while its version
+// stamp is current; stale tokens fall back to the cached lookup.
+func (p *Permissions) Require(requiredPermission string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			uid := r.Context().Value(UserIDKey)
@@ -19,31 +173,44 @@ func RequirePermission(requiredPermission string) func(http.Handler) http.Handle
 			}
 			userID := uid.(int64)
 
-			db, err := tools.NewDatabase(5, 3*time.Second)
-			if err != nil {
-				log.Error("Failed to connect to DB in RequirePermission: ", err)
-				http.Error(w, "Server error", http.StatusInternalServerError)
-				return
+			granted, ok := p.fromToken(r)
+			if !ok {
+				var err error
+				granted, err = p.Granted(userID)
+				if err != nil {
+					log.Warnf("Failed to get permissions for user %d: %v", userID, err)
+					http.Error(w, "Forbidden", http.StatusForbidden)
+					return
+				}
 			}
-			defer db.Close()
 
-			perms, err := db.GetUserPermissions(userID)
-			if err != nil {
-				log.Warnf("Failed to get permissions for user %d: %v", userID, err)
-				http.Error(w, "Forbidden", http.StatusForbidden)
+			if granted[requiredPermission] {
+				// User has the required permission
+				next.ServeHTTP(w, r)
 				return
 			}
 
-			for _, p := range perms {
-				if p.Name == requiredPermission {
-					// User has the required permission
-					next.ServeHTTP(w, r)
-					return
-				}
-			}
-
 			log.Warnf("User %d does not have required permission: %s", userID, requiredPermission)
 			http.Error(w, "Forbidden", http.StatusForbidden)
 		})
 	}
 }
+
+// fromToken returns the permission set embedded in the request's token if it
+// carries the current version stamp
+func (p *Permissions) fromToken(r *http.Request) (map[string]bool, bool) {
+	claims, ok := r.Context().Value(tokenPermissionsKey).(tokenPermissions)
+	if !ok {
+		return nil, false
+	}
+	current, err := p.Version()
+	if err != nil {
+		log.Warnf("Failed to read permission version: %v", err)
+		return nil, false
+	}
+	if claims.version != current {
+		log.Debugf("Token permission version %d is stale (current %d)", claims.version, current)
+		return nil, false
+	}
+	return claims.names, true
+}
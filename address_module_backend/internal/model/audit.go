@@ -0,0 +1,52 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit log actions
+const (
+	AuditActionCreate         = "create"
+	AuditActionUpdate         = "update"
+	AuditActionDelete         = "delete"
+	AuditActionRestore        = "restore"
+	AuditActionRevokeSessions = "revoke_sessions"
+)
+
+// Audited entity types
+const (
+	AuditEntityFirm           = "firm"
+	AuditEntityContact        = "contact"
+	AuditEntityFirmContact    = "firm_contact" // entity ID is the firm
+	AuditEntityUser           = "user"
+	AuditEntityRole           = "role"
+	AuditEntityPermission     = "permission"
+	AuditEntityUserRole       = "user_role"       // entity ID is the user
+	AuditEntityRolePermission = "role_permission" // entity ID is the role
+	AuditEntityDevice         = "device"
+	AuditEntityDeviceLink     = "device_link"
+)
+
+// AuditEntry is one row of the append-only audit log. Changes holds
+// {"before": {...}, "after": {...}} with only the fields that changed;
+// before is null for creates and after is null for deletes.
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UserID     *int64          `json:"user_id"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   int64           `json:"entity_id"`
+	Changes    json.RawMessage `json:"changes"`
+}
+
+// AuditFilter selects audit entries; zero values match everything
+type AuditFilter struct {
+	EntityType string
+	EntityID   *int64
+	UserID     *int64
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+}
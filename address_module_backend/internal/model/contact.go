@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // ContactParams represents the expected structure for a contact request
 type ContactParams struct {
 	Anrede     string  `json:"anrede"`
@@ -18,16 +20,32 @@ type ContactParams struct {
 
 // ContactResponse represents a contact returned in API responses
 type ContactResponse struct {
-	ID         int64  `json:"id"`
-	Anrede     string `json:"anrede"`
-	Vorname    string `json:"vorname"`
-	Nachname   string `json:"nachname"`
-	Position   string `json:"position"`
-	Telefon    string `json:"telefon"`
-	Mobil      string `json:"mobil"`
-	Email      string `json:"email"`
-	Abteilung  string `json:"abteilung"`
-	Geburtstag string `json:"geburtstag"`
-	Bemerkung  string `json:"bemerkung"`
-	Kontotyp   string `json:"kontotyp"`
+	ID         int64      `json:"id"`
+	Anrede     string     `json:"anrede"`
+	Vorname    string     `json:"vorname"`
+	Nachname   string     `json:"nachname"`
+	Position   string     `json:"position"`
+	Telefon    string     `json:"telefon"`
+	Mobil      string     `json:"mobil"`
+	Email      string     `json:"email"`
+	Abteilung  string     `json:"abteilung"`
+	Geburtstag string     `json:"geburtstag"`
+	Bemerkung  string     `json:"bemerkung"`
+	Kontotyp   string     `json:"kontotyp"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy  *int64     `json:"deleted_by,omitempty"`
+}
+
+// ContactUpdateParams is the body of /contact/update. All contact fields are
+// replaced; the firm relations are only changed by add_firms and remove_firms.
+type ContactUpdateParams struct {
+	ContactResponse
+	AddFirms    []int64 `json:"add_firms"`
+	RemoveFirms []int64 `json:"remove_firms"`
+}
+
+// ContactDetail is a single contact with its firms embedded
+type ContactDetail struct {
+	ContactResponse
+	Firms []ContactFirmResponse `json:"firms"`
 }
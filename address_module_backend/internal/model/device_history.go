@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// DeviceFieldChange is one field that differs between two device versions.
+// Field is the JSON name used by /devices/get.
+type DeviceFieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// DeviceVersion is one entry of a device's history. Version 1 is the state
+// before the first recorded update and has no changes.
+type DeviceVersion struct {
+	Version      int                 `json:"version"`
+	ChangedAt    time.Time           `json:"changed_at"`
+	ChangedBy    *int64              `json:"changed_by"`
+	RevertedFrom *int                `json:"reverted_from,omitempty"`
+	Changes      []DeviceFieldChange `json:"changes"`
+}
+
+// DeviceRevertRequest is the body of /devices/revert
+type DeviceRevertRequest struct {
+	DeviceID int64 `json:"device_id"`
+	Version  int   `json:"version"`
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 // FirmParams represents the expected structure of the request body
 type FirmParams struct {
 	Anrede     string  `json:"anrede"`
@@ -24,21 +26,52 @@ type FirmParams struct {
 
 // FirmResponse represents a firm returned in API responses
 type FirmResponse struct {
-	ID        int64  `json:"id"`
-	Anrede    string `json:"anrede"`
-	Name1     string `json:"name_1"`
-	Name2     string `json:"name_2"`
-	Name3     string `json:"name_3"`
-	Straße    string `json:"straße"`
-	Land      string `json:"land"`
-	PLZ       string `json:"plz"`
-	Ort       string `json:"ort"`
-	Telefon   string `json:"telefon"`
-	Email     string `json:"email"`
-	Website   string `json:"website"`
-	Kunde     bool   `json:"kunde"`
-	Lieferant bool   `json:"lieferant"`
-	Gesperrt  bool   `json:"gesperrt"`
-	Bemerkung string `json:"bemerkung"`
-	FirmaTyp  string `json:"firma_typ"`
+	ID        int64      `json:"id"`
+	Anrede    string     `json:"anrede"`
+	Name1     string     `json:"name_1"`
+	Name2     string     `json:"name_2"`
+	Name3     string     `json:"name_3"`
+	Straße    string     `json:"straße"`
+	Land      string     `json:"land"`
+	PLZ       string     `json:"plz"`
+	Ort       string     `json:"ort"`
+	Telefon   string     `json:"telefon"`
+	Email     string     `json:"email"`
+	Website   string     `json:"website"`
+	Kunde     bool       `json:"kunde"`
+	Lieferant bool       `json:"lieferant"`
+	Gesperrt  bool       `json:"gesperrt"`
+	Bemerkung string     `json:"bemerkung"`
+	FirmaTyp  string     `json:"firma_typ"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy *int64     `json:"deleted_by,omitempty"`
+}
+
+// FirmDetail is a single firm with its contacts embedded
+type FirmDetail struct {
+	FirmResponse
+	Contacts []FirmContactResponse `json:"contacts"`
+}
+
+// FirmContactRelationParams is the body of /firm/contact_relation. It links the
+// contact to the firm if needed; only one contact per firm can be primary.
+type FirmContactRelationParams struct {
+	FirmaID              int64  `json:"firma_id"`
+	ContactID            int64  `json:"contact_id"`
+	Beziehung            string `json:"beziehung"`            // Free text role, e.g. "Projektleitung IT-Systeme"
+	Hauptansprechpartner bool   `json:"hauptansprechpartner"` // Primary contact of the firm
+}
+
+// FirmContactResponse is a contact listed under a firm, with its role there
+type FirmContactResponse struct {
+	ContactResponse
+	Beziehung            string `json:"beziehung"`
+	Hauptansprechpartner bool   `json:"hauptansprechpartner"`
+}
+
+// ContactFirmResponse is a firm listed under a contact, with the contact's role there
+type ContactFirmResponse struct {
+	FirmResponse
+	Beziehung            string `json:"beziehung"`
+	Hauptansprechpartner bool   `json:"hauptansprechpartner"`
 }
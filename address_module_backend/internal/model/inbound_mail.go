@@ -0,0 +1,26 @@
+package model
+
+// InboundMail is a parsed customer email
+type InboundMail struct {
+	MessageID   string              `json:"message_id"`
+	From        string              `json:"from"` // bare address, lower-cased
+	FromName    string              `json:"from_name"`
+	Subject     string              `json:"subject"`
+	Body        string              `json:"body"` // plain text; HTML-only mails are reduced to text
+	Attachments []InboundAttachment `json:"attachments"`
+}
+
+// InboundAttachment is a file attached to an inbound email
+type InboundAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+	Data        []byte `json:"-"`
+}
+
+// InboundMailResult tells the caller what happened to an ingested email
+type InboundMailResult struct {
+	Action    string `json:"action"` // "created", "threaded" or "duplicate"
+	TicketID  *int64 `json:"ticket_id,omitempty"`
+	CommentID *int64 `json:"comment_id,omitempty"`
+}
@@ -0,0 +1,169 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Page size limits shared by all list endpoints
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+// FilterKind tells ParseListQuery how to interpret a filter value
+type FilterKind int
+
+const (
+	FilterString FilterKind = iota
+	FilterBool
+	FilterInt
+)
+
+// ListFilterField is a column clients may filter on with ?<name>=<value>
+type ListFilterField struct {
+	Column string
+	Kind   FilterKind
+}
+
+// ListSpec describes what a list endpoint allows. Sort maps a field name to a
+// SQL expression that must never be NULL, otherwise cursors skip rows.
+// IDColumn is appended to every sort so the order is total.
+type ListSpec struct {
+	IDColumn    string
+	DefaultSort string // e.g. "-id"
+	Sort        map[string]string
+	Filters     map[string]ListFilterField
+}
+
+// SortField is one column of the requested order
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// ListFilter is one equality filter taken from the query string
+type ListFilter struct {
+	Column string
+	Value  interface{}
+}
+
+// ListQuery is the parsed paging, sorting and filtering of a list request.
+// Page > 0 selects offset paging; otherwise Cursor (nil for the first page)
+// continues after the last row of the previous page.
+type ListQuery struct {
+	Limit   int
+	Page    int
+	Cursor  []string
+	Sort    []SortField
+	Filters []ListFilter
+	// IncludeDeleted shows soft-deleted rows; set by the handler after checking view_deleted
+	IncludeDeleted bool
+}
+
+// ListMeta is returned next to every list so clients can fetch further pages
+type ListMeta struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Page       int    `json:"page,omitempty"`
+	NextPage   int    `json:"next_page,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ParseListQuery reads ?limit=, ?page= or ?cursor=, ?sort= and the filters
+// allowed by spec. Parameters the spec does not know are ignored.
+func ParseListQuery(v url.Values, spec ListSpec) (ListQuery, error) {
+	q := ListQuery{Limit: DefaultListLimit}
+
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > MaxListLimit {
+			return q, fmt.Errorf("limit must be between 1 and %d", MaxListLimit)
+		}
+		q.Limit = n
+	}
+
+	if s := v.Get("page"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return q, fmt.Errorf("page must be a positive number")
+		}
+		q.Page = n
+	}
+
+	sort := v.Get("sort")
+	if sort == "" {
+		sort = spec.DefaultSort
+	}
+	hasID := false
+	for _, field := range strings.Split(sort, ",") {
+		field = strings.TrimSpace(field)
+		desc := strings.HasPrefix(field, "-")
+		field = strings.TrimPrefix(field, "-")
+		column, ok := spec.Sort[field]
+		if !ok {
+			return q, fmt.Errorf("cannot sort by %q", field)
+		}
+		hasID = hasID || column == spec.IDColumn
+		q.Sort = append(q.Sort, SortField{Column: column, Desc: desc})
+	}
+	if !hasID {
+		q.Sort = append(q.Sort, SortField{Column: spec.IDColumn, Desc: q.Sort[len(q.Sort)-1].Desc})
+	}
+
+	if s := v.Get("cursor"); s != "" {
+		if q.Page > 0 {
+			return q, fmt.Errorf("use either page or cursor, not both")
+		}
+		cursor, err := decodeCursor(s)
+		if err != nil || len(cursor) != len(q.Sort) {
+			return q, fmt.Errorf("invalid cursor")
+		}
+		q.Cursor = cursor
+	}
+
+	for name, field := range spec.Filters {
+		s, ok := v[name]
+		if !ok || len(s) == 0 {
+			continue
+		}
+		var value interface{} = s[0]
+		switch field.Kind {
+		case FilterBool:
+			b, err := strconv.ParseBool(s[0])
+			if err != nil {
+				return q, fmt.Errorf("%s must be true or false", name)
+			}
+			value = b
+		case FilterInt:
+			n, err := strconv.ParseInt(s[0], 10, 64)
+			if err != nil {
+				return q, fmt.Errorf("%s must be a number", name)
+			}
+			value = n
+		}
+		q.Filters = append(q.Filters, ListFilter{Column: field.Column, Value: value})
+	}
+
+	return q, nil
+}
+
+// EncodeCursor packs the sort values of the last row into an opaque token
+func EncodeCursor(values []string) string {
+	b, _ := json.Marshal(values)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) ([]string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	err = json.Unmarshal(b, &values)
+	return values, err
+}
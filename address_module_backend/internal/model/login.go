@@ -6,5 +6,28 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"` // seconds until Token expires
+}
+
+// RefreshRequest is the body of POST /auth/refresh
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// ForgotPasswordRequest is the body of POST /auth/forgot and /auth/verify/resend
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest is the body of POST /auth/reset
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// VerifyEmailRequest is the body of POST /auth/verify
+type VerifyEmailRequest struct {
 	Token string `json:"token"`
 }
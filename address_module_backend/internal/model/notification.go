@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Email outbox statuses
+const (
+	EmailStatusPending = "pending"
+	EmailStatusSent    = "sent"
+	EmailStatusFailed  = "failed" // gave up after the maximum number of attempts
+)
+
+// OutboxEmail is a queued notification; the dispatcher sends it and retries on failure
+type OutboxEmail struct {
+	ID            int64      `json:"id"`
+	TicketID      *int64     `json:"ticket_id,omitempty"`
+	Event         string     `json:"event"`
+	Recipient     string     `json:"recipient"`
+	Subject       string     `json:"subject"`
+	Body          string     `json:"body"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
+
+// TicketRecipients are the people notified about a ticket; empty emails are skipped
+type TicketRecipients struct {
+	ContactID     *int64
+	ContactName   string
+	ContactEmail  string
+	AssigneeID    *int64
+	AssigneeName  string
+	AssigneeEmail string
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// Queue assignment modes
+const (
+	QueueModeManual     = "manual"      // tickets wait in the queue until someone assigns them
+	QueueModeRoundRobin = "round_robin" // members take turns
+	QueueModeLeastOpen  = "least_open"  // the member with the fewest open tickets gets the next one
+)
+
+// Queue is a support group that tickets can be routed to
+type Queue struct {
+	ID             int64   `json:"id"`
+	Name           string  `json:"name"`
+	Description    string  `json:"description"`
+	AssignmentMode string  `json:"assignment_mode"`
+	RequiredRoleID *int64  `json:"required_role_id,omitempty"` // Only members holding this role are auto-assigned
+	MemberIDs      []int64 `json:"member_ids"`
+}
+
+// TicketAssignRequest moves a ticket into a queue and/or to a user.
+// With a queue but no assignee, an automatic queue picks the assignee;
+// a null queue_id takes the ticket out of its queue.
+type TicketAssignRequest struct {
+	TicketID   int64  `json:"ticket_id"`
+	QueueID    *int64 `json:"queue_id"`
+	AssigneeID *int64 `json:"assignee_id"`
+}
+
+// Validate checks the queue and fills in defaults
+func (q *Queue) Validate() error {
+	q.Name = strings.TrimSpace(q.Name)
+	if q.Name == "" {
+		return errors.New("queue name is required")
+	}
+	if q.AssignmentMode == "" {
+		q.AssignmentMode = QueueModeManual
+	}
+	switch q.AssignmentMode {
+	case QueueModeManual, QueueModeRoundRobin, QueueModeLeastOpen:
+	default:
+		return errors.New("assignment_mode must be manual, round_robin or least_open")
+	}
+	if q.MemberIDs == nil {
+		q.MemberIDs = []int64{}
+	}
+	return nil
+}
+
+// AutoAssigns reports whether the queue picks assignees by itself
+func (q *Queue) AutoAssigns() bool {
+	return q.AssignmentMode == QueueModeRoundRobin || q.AssignmentMode == QueueModeLeastOpen
+}
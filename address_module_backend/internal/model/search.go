@@ -0,0 +1,46 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Search result types
+const (
+	SearchTypeFirm    = "firm"
+	SearchTypeContact = "contact"
+	SearchTypeTicket  = "ticket"
+	SearchTypeDevice  = "device"
+)
+
+// SearchTypes lists every searchable type in the order results are merged
+var SearchTypes = []string{SearchTypeFirm, SearchTypeContact, SearchTypeTicket, SearchTypeDevice}
+
+// SearchResult is a single hit of the unified search
+type SearchResult struct {
+	Type     string  `json:"type"`
+	ID       int64   `json:"id"`
+	Title    string  `json:"title"`
+	Subtitle string  `json:"subtitle"`
+	Score    float64 `json:"score"` // Relevance normalised to 0..1 within its type
+}
+
+// SearchTerms splits a query into words usable by both full-text engines.
+// Operator characters of MySQL boolean mode and Postgres tsquery syntax are
+// dropped; dots, dashes, colons and @ are kept so IPs, MACs and emails survive.
+func SearchTerms(q string) []string {
+	var terms []string
+	for _, word := range strings.Fields(q) {
+		word = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(".-:@_", r) {
+				return r
+			}
+			return -1
+		}, word)
+		word = strings.Trim(word, ".-:@_")
+		if word != "" {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// RefreshToken is one link in a session's rotation chain. All tokens rotated
+// from the same login share a FamilyID, which access tokens carry as "sid".
+type RefreshToken struct {
+	ID              int64      `json:"id"`
+	UserID          int64      `json:"user_id"`
+	FamilyID        string     `json:"family_id"`
+	TokenHash       string     `json:"-"`
+	AccessJTI       string     `json:"access_jti"`
+	AccessExpiresAt time.Time  `json:"access_expires_at"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UsedAt          *time.Time `json:"used_at,omitempty"`
+	RevokedAt       *time.Time `json:"revoked_at,omitempty"`
+	ReplacedBy      *int64     `json:"replaced_by,omitempty"`
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// SLAPolicy defines response and resolution targets for a support contract.
+// Targets are measured in business minutes according to the policy's calendar.
+type SLAPolicy struct {
+	ID                 int64        `json:"id"`
+	Name               string       `json:"name"`
+	Description        string       `json:"description"`
+	Timezone           string       `json:"timezone"`             // IANA name, e.g. Europe/Berlin
+	BusinessHoursStart string       `json:"business_hours_start"` // HH:MM
+	BusinessHoursEnd   string       `json:"business_hours_end"`   // HH:MM
+	WorkDays           []int        `json:"work_days"`            // 0 = Sunday ... 6 = Saturday
+	Targets            []SLATarget  `json:"targets"`
+	Holidays           []SLAHoliday `json:"holidays"`
+}
+
+// SLATarget holds the deadlines for one ticket priority
+type SLATarget struct {
+	Priority             string `json:"priority"`
+	FirstResponseMinutes int    `json:"first_response_minutes"`
+	ResolutionMinutes    int    `json:"resolution_minutes"`
+}
+
+// SLAHoliday is a non-working day for a policy
+type SLAHoliday struct {
+	Date        string `json:"date"` // YYYY-MM-DD
+	Description string `json:"description,omitempty"`
+}
+
+// SLAAssignment is the body of PUT /sla/assign; a nil policy removes the firm's SLA
+type SLAAssignment struct {
+	FirmID   int64  `json:"firm_id"`
+	PolicyID *int64 `json:"policy_id"`
+}
+
+// SLABreach is a ticket that is overdue or about to breach one of its deadlines
+type SLABreach struct {
+	Ticket
+	Breach  string    `json:"breach"` // "first_response" or "resolution"
+	DueAt   time.Time `json:"due_at"`
+	Overdue bool      `json:"overdue"`
+}
+
+// Target returns the target for a priority, if the policy defines one
+func (p *SLAPolicy) Target(priority string) (SLATarget, bool) {
+	for _, t := range p.Targets {
+		if t.Priority == priority {
+			return t, true
+		}
+	}
+	return SLATarget{}, false
+}
+
+// Validate checks the policy and fills in defaults
+func (p *SLAPolicy) Validate() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return errors.New("policy name is required")
+	}
+	if p.Timezone == "" {
+		p.Timezone = "Europe/Berlin"
+	}
+	if _, err := time.LoadLocation(p.Timezone); err != nil {
+		return errors.New("unknown timezone " + p.Timezone)
+	}
+	if p.BusinessHoursStart == "" {
+		p.BusinessHoursStart = "08:00"
+	}
+	if p.BusinessHoursEnd == "" {
+		p.BusinessHoursEnd = "17:00"
+	}
+	start, err := time.Parse("15:04", p.BusinessHoursStart)
+	if err != nil {
+		return errors.New("business_hours_start must be HH:MM")
+	}
+	end, err := time.Parse("15:04", p.BusinessHoursEnd)
+	if err != nil {
+		return errors.New("business_hours_end must be HH:MM")
+	}
+	if !end.After(start) {
+		return errors.New("business hours must end after they start")
+	}
+	if len(p.WorkDays) == 0 {
+		p.WorkDays = []int{1, 2, 3, 4, 5}
+	}
+	for _, d := range p.WorkDays {
+		if d < 0 || d > 6 {
+			return errors.New("work_days must be between 0 (Sunday) and 6 (Saturday)")
+		}
+	}
+	for _, t := range p.Targets {
+		if !ValidTicketPriority(t.Priority) {
+			return errors.New("unknown priority " + t.Priority)
+		}
+		if t.FirstResponseMinutes <= 0 || t.ResolutionMinutes <= 0 {
+			return errors.New("target minutes must be positive")
+		}
+	}
+	for _, h := range p.Holidays {
+		if _, err := time.Parse("2006-01-02", h.Date); err != nil {
+			return errors.New("holiday dates must be YYYY-MM-DD")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Ticket priorities
+const (
+	TicketPriorityLow      = "low"
+	TicketPriorityNormal   = "normal"
+	TicketPriorityHigh     = "high"
+	TicketPriorityCritical = "critical"
+)
+
+// Statuses of the default workflow that other parts of the system react to
+const (
+	TicketStatusNew      = "new"
+	TicketStatusResolved = "resolved"
+)
+
+// Ticket represents a support ticket
+type Ticket struct {
+	ID          int64     `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	Priority    string    `json:"priority"`
+	ContactID   *int64    `json:"contact_id,omitempty"`  // Requester (contacts.id)
+	AssigneeID  *int64    `json:"assignee_id,omitempty"` // Assigned user (users.id)
+	FirmaID     *int64    `json:"firma_id,omitempty"`    // Firm (firms.id)
+	WorkflowID  *int64    `json:"workflow_id,omitempty"` // Workflow governing status changes; nil means the default workflow
+	QueueID     *int64    `json:"queue_id,omitempty"`    // Support queue; changed through /tickets/assign, not /tickets/update
+	CreatedBy   *int64    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// SLA deadlines, computed from the firm's SLA policy; nil when no policy applies
+	FirstResponseDueAt *time.Time `json:"first_response_due_at,omitempty"`
+	ResolutionDueAt    *time.Time `json:"resolution_due_at,omitempty"`
+	FirstRespondedAt   *time.Time `json:"first_responded_at,omitempty"`
+	EscalatedAt        *time.Time `json:"escalated_at,omitempty"`
+
+	DeviceIDs []int64 `json:"device_ids,omitempty"` // Devices from the device management database; nil on update leaves links untouched
+
+	// ContactEmail lets clients name the requester by email instead of contact_id.
+	// It is resolved against contacts.email on creation and never stored.
+	ContactEmail string `json:"contact_email,omitempty"`
+}
+
+// TicketDevice is a device linked to a ticket. Name, hostname, IP and location
+// are snapshotted when linking so the ticket history survives device deletion.
+type TicketDevice struct {
+	DeviceID     int64  `json:"device_id"`
+	Name         string `json:"name"`
+	Hostname     string `json:"hostname"`
+	IP           string `json:"ip"`
+	LocationText string `json:"location_text"`
+	Deleted      bool   `json:"deleted"` // Device no longer exists in the device management database
+	Source       string `json:"source"`  // "live" when read from the device database, "snapshot" otherwise
+}
+
+// TicketDetail is a ticket with its related data embedded
+type TicketDetail struct {
+	Ticket
+	Devices []TicketDevice `json:"devices"`
+}
+
+// ValidTicketPriority reports whether p is a known ticket priority
+func ValidTicketPriority(p string) bool {
+	switch p {
+	case TicketPriorityLow, TicketPriorityNormal, TicketPriorityHigh, TicketPriorityCritical:
+		return true
+	}
+	return false
+}
+
+// TicketToken is the tag put into email subjects so replies can be matched to their ticket
+func TicketToken(id int64) string {
+	return fmt.Sprintf("[Ticket#%d]", id)
+}
+
+var ticketTokenPattern = regexp.MustCompile(`(?i)\[Ticket#(\d+)\]`)
+
+// ParseTicketToken finds a TicketToken in an email subject and returns the ticket ID
+func ParseTicketToken(subject string) (int64, bool) {
+	m := ticketTokenPattern.FindStringSubmatch(subject)
+	if m == nil {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(m[1], 10, 64)
+	return id, err == nil
+}
+
+// StripTicketToken removes every TicketToken from a subject
+func StripTicketToken(subject string) string {
+	return ticketTokenPattern.ReplaceAllString(subject, "")
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// TicketAttachment is a file uploaded to a ticket or to one of its comments.
+// The content lives in blob storage under its SHA-256 hash.
+type TicketAttachment struct {
+	ID          int64     `json:"id"`
+	TicketID    int64     `json:"ticket_id"`
+	CommentID   *int64    `json:"comment_id,omitempty"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	UploadedBy  *int64    `json:"uploaded_by,omitempty"`
+	Internal    bool      `json:"internal"` // Attached to an internal note
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// TicketComment is an entry in a ticket's conversation.
+// Internal notes are only visible to users with the view_internal_notes permission.
+type TicketComment struct {
+	ID              int64     `json:"id"`
+	TicketID        int64     `json:"ticket_id"`
+	AuthorID        *int64    `json:"author_id,omitempty"`         // Set from the authenticated user, never from the request body
+	AuthorContactID *int64    `json:"author_contact_id,omitempty"` // Set when the comment came in by email from a contact
+	Body            string    `json:"body"`
+	Internal        bool      `json:"internal"`
+	CreatedAt       time.Time `json:"created_at"`
+}
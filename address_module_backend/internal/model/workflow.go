@@ -0,0 +1,138 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Workflow describes the allowed status transitions for tickets
+type Workflow struct {
+	ID            int64                `json:"id"`
+	Name          string               `json:"name"`
+	Description   string               `json:"description"`
+	InitialStatus string               `json:"initial_status"`
+	IsDefault     bool                 `json:"is_default"`
+	Transitions   []WorkflowTransition `json:"transitions"`
+	// ClosedStatuses end the ticket: SLA deadlines stop, no escalation, and the
+	// ticket no longer counts as open work for its assignee
+	ClosedStatuses []string `json:"closed_statuses"`
+}
+
+// WorkflowTransition is a single allowed move between two statuses
+type WorkflowTransition struct {
+	ID         int64  `json:"id,omitempty"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	Name       string `json:"name,omitempty"` // Label shown to agents, e.g. "Reopen"
+}
+
+// TicketTransitionRequest is the body of POST /tickets/transition
+type TicketTransitionRequest struct {
+	TicketID int64  `json:"ticket_id"`
+	ToStatus string `json:"to_status"`
+	Comment  string `json:"comment"`
+}
+
+// TicketTransition is a recorded status change of a ticket
+type TicketTransition struct {
+	ID         int64     `json:"id"`
+	TicketID   int64     `json:"ticket_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ChangedBy  *int64    `json:"changed_by,omitempty"`
+	Comment    string    `json:"comment,omitempty"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// CanTransition reports whether the workflow allows moving from one status to another
+func (w *Workflow) CanTransition(from, to string) bool {
+	for _, t := range w.Transitions {
+		if t.FromStatus == from && t.ToStatus == to {
+			return true
+		}
+	}
+	return false
+}
+
+// NextStatuses returns the statuses reachable from the given status
+func (w *Workflow) NextStatuses(from string) []string {
+	var next []string
+	for _, t := range w.Transitions {
+		if t.FromStatus == from {
+			next = append(next, t.ToStatus)
+		}
+	}
+	return next
+}
+
+// HasStatus reports whether a ticket can be in the given status under the workflow
+func (w *Workflow) HasStatus(status string) bool {
+	if status == w.InitialStatus {
+		return true
+	}
+	for _, t := range w.Transitions {
+		if t.FromStatus == status || t.ToStatus == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsClosed reports whether the status is one of the workflow's closed statuses
+func (w *Workflow) IsClosed(status string) bool {
+	for _, s := range w.ClosedStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the workflow definition is usable. A workflow without
+// transitions is allowed and keeps tickets in their initial status; otherwise
+// tickets must be able to leave the initial status.
+func (w *Workflow) Validate() error {
+	w.Name = strings.TrimSpace(w.Name)
+	w.InitialStatus = strings.TrimSpace(w.InitialStatus)
+	if w.Name == "" || w.InitialStatus == "" {
+		return errors.New("workflow name and initial_status are required")
+	}
+	seen := make(map[string]bool)
+	for i := range w.Transitions {
+		t := &w.Transitions[i]
+		t.FromStatus = strings.TrimSpace(t.FromStatus)
+		t.ToStatus = strings.TrimSpace(t.ToStatus)
+		if t.FromStatus == "" || t.ToStatus == "" {
+			return errors.New("every transition needs from_status and to_status")
+		}
+		if t.FromStatus == t.ToStatus {
+			return errors.New("a transition must change the status")
+		}
+		key := t.FromStatus + "\x00" + t.ToStatus
+		if seen[key] {
+			return errors.New("duplicate transition " + t.FromStatus + " -> " + t.ToStatus)
+		}
+		seen[key] = true
+	}
+	if len(w.Transitions) > 0 && len(w.NextStatuses(w.InitialStatus)) == 0 {
+		return errors.New("initial_status " + w.InitialStatus + " needs an outgoing transition")
+	}
+
+	closed := make(map[string]bool)
+	for i, status := range w.ClosedStatuses {
+		status = strings.TrimSpace(status)
+		w.ClosedStatuses[i] = status
+		if closed[status] {
+			return errors.New("duplicate closed status " + status)
+		}
+		closed[status] = true
+		if status == w.InitialStatus {
+			return errors.New("the initial status cannot be a closed status")
+		}
+		if !w.HasStatus(status) {
+			return errors.New("closed status " + status + " is not part of the workflow")
+		}
+	}
+	return nil
+}
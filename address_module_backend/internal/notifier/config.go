@@ -0,0 +1,72 @@
+package notifier
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Config holds the SMTP and notification settings read from the environment
+type Config struct {
+	Host             string        // SMTP_HOST; notifications are only dispatched when set
+	Port             string        // SMTP_PORT, default 587
+	Username         string        // SMTP_USER; no authentication when empty
+	Password         string        // SMTP_PASSWORD
+	From             string        // SMTP_FROM, e.g. "Support <support@example.com>"
+	Language         string        // NOTIFY_LANGUAGE, "de" (default) or "en"
+	MaxAttempts      int           // SMTP_MAX_ATTEMPTS, default 8
+	DispatchInterval time.Duration // NOTIFY_DISPATCH_INTERVAL, default 30s
+}
+
+// ConfigFromEnv reads the notifier configuration, like NewDatabase does for MYSQL_*
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Host:             os.Getenv("SMTP_HOST"),
+		Port:             os.Getenv("SMTP_PORT"),
+		Username:         os.Getenv("SMTP_USER"),
+		Password:         os.Getenv("SMTP_PASSWORD"),
+		From:             os.Getenv("SMTP_FROM"),
+		Language:         os.Getenv("NOTIFY_LANGUAGE"),
+		MaxAttempts:      8,
+		DispatchInterval: 30 * time.Second,
+	}
+
+	if cfg.Port == "" {
+		cfg.Port = "587"
+	}
+	if cfg.From == "" {
+		cfg.From = "noreply@localhost"
+	}
+	if _, ok := templates[cfg.Language]; !ok {
+		if cfg.Language != "" {
+			log.Warnf("Unsupported NOTIFY_LANGUAGE %q, falling back to de", cfg.Language)
+		}
+		cfg.Language = "de"
+	}
+	if v := os.Getenv("SMTP_MAX_ATTEMPTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cfg.MaxAttempts = n
+		} else {
+			log.Warnf("Invalid SMTP_MAX_ATTEMPTS=%q, using %d", v, cfg.MaxAttempts)
+		}
+	}
+	if v := os.Getenv("NOTIFY_DISPATCH_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			cfg.DispatchInterval = d
+		} else {
+			log.Warnf("Invalid NOTIFY_DISPATCH_INTERVAL=%q, using %s", v, cfg.DispatchInterval)
+		}
+	}
+
+	log.Infof("📧 SMTP_HOST: %s", cfg.Host)
+	log.Infof("📦 SMTP_PORT: %s", cfg.Port)
+	log.Infof("✉️  SMTP_FROM: %s", cfg.From)
+	return cfg
+}
+
+// Enabled reports whether an SMTP server is configured
+func (c Config) Enabled() bool {
+	return c.Host != ""
+}
@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"address_module/internal/model"
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// OutboxStore is what the dispatcher needs from the database
+type OutboxStore interface {
+	GetDueEmails(now time.Time, limit int) ([]model.OutboxEmail, error)
+	MarkEmailSent(id int64, at time.Time) error
+	MarkEmailFailed(id int64, sendErr error, retryAt time.Time) error
+}
+
+// Dispatcher is a scheduler job that delivers queued emails and retries
+// failures with exponential backoff until MaxAttempts is reached
+type Dispatcher struct {
+	Store       OutboxStore
+	Sender      Sender
+	MaxAttempts int
+	Every       time.Duration
+	BatchSize   int
+}
+
+// NewDispatcher creates the outbox job for a configuration
+func NewDispatcher(store OutboxStore, sender Sender, cfg Config) *Dispatcher {
+	return &Dispatcher{
+		Store:       store,
+		Sender:      sender,
+		MaxAttempts: cfg.MaxAttempts,
+		Every:       cfg.DispatchInterval,
+		BatchSize:   50,
+	}
+}
+
+func (d *Dispatcher) Name() string            { return "email_outbox" }
+func (d *Dispatcher) Interval() time.Duration { return d.Every }
+
+// backoff returns the wait before the next attempt: 1m, 2m, 4m, ... capped at 6h
+func backoff(attempts int) time.Duration {
+	wait := time.Minute << uint(attempts)
+	if attempts > 10 || wait > 6*time.Hour {
+		return 6 * time.Hour
+	}
+	return wait
+}
+
+func (d *Dispatcher) Run(ctx context.Context, now time.Time) error {
+	emails, err := d.Store.GetDueEmails(now, d.BatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range emails {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		sendErr := d.Sender.Send(e.Recipient, e.Subject, e.Body)
+		if sendErr == nil {
+			if err := d.Store.MarkEmailSent(e.ID, now); err != nil {
+				return err
+			}
+			continue
+		}
+
+		attempts := e.Attempts + 1
+		var retryAt time.Time
+		if attempts < d.MaxAttempts {
+			retryAt = now.Add(backoff(e.Attempts))
+		}
+		log.WithFields(log.Fields{
+			"email_id": e.ID,
+			"attempt":  attempts,
+		}).Warnf("Sending email failed: %v", sendErr)
+		if err := d.Store.MarkEmailFailed(e.ID, sendErr, retryAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package notifier
+
+import (
+	"address_module/internal/model"
+	"strings"
+	"time"
+)
+
+// Store is what the notifier needs from the database
+type Store interface {
+	GetTicketRecipients(ticketID int64) (*model.TicketRecipients, error)
+	EnqueueEmail(email model.OutboxEmail) (int64, error)
+}
+
+// Notifier turns ticket events into queued emails. Nothing is sent directly;
+// the Dispatcher delivers the outbox so sends survive restarts and SMTP outages.
+type Notifier struct {
+	store Store
+	lang  string
+}
+
+// New creates a notifier writing to the given store in the given language
+func New(store Store, lang string) *Notifier {
+	if _, ok := templates[lang]; !ok {
+		lang = "de"
+	}
+	return &Notifier{store: store, lang: lang}
+}
+
+type recipient struct {
+	name  string
+	email string
+}
+
+func (n *Notifier) enqueue(event string, ticket model.Ticket, comment string, to ...recipient) error {
+	seen := make(map[string]bool)
+	for _, r := range to {
+		addr := strings.ToLower(strings.TrimSpace(r.email))
+		if addr == "" || seen[addr] {
+			continue
+		}
+		seen[addr] = true
+
+		subject, body, err := render(n.lang, event, templateData{
+			Token:     model.TicketToken(ticket.ID),
+			Ticket:    ticket,
+			Recipient: r.name,
+			Comment:   comment,
+		})
+		if err != nil {
+			return err
+		}
+
+		ticketID := ticket.ID
+		if _, err := n.store.EnqueueEmail(model.OutboxEmail{
+			TicketID:  &ticketID,
+			Event:     event,
+			Recipient: r.email,
+			Subject:   subject,
+			Body:      body,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TicketCreated confirms a new ticket to its requester
+func (n *Notifier) TicketCreated(ticket model.Ticket) error {
+	r, err := n.store.GetTicketRecipients(ticket.ID)
+	if err != nil {
+		return err
+	}
+	return n.enqueue(EventTicketCreated, ticket, "", recipient{r.ContactName, r.ContactEmail})
+}
+
+// TicketAssigned tells the assignee about their new ticket
+func (n *Notifier) TicketAssigned(ticket model.Ticket) error {
+	r, err := n.store.GetTicketRecipients(ticket.ID)
+	if err != nil {
+		return err
+	}
+	return n.enqueue(EventTicketAssigned, ticket, "", recipient{r.AssigneeName, r.AssigneeEmail})
+}
+
+// TicketCommented forwards a comment. Public replies go to the requester and the
+// assignee, internal notes only to the assignee; the author is never notified.
+func (n *Notifier) TicketCommented(ticket model.Ticket, comment model.TicketComment) error {
+	r, err := n.store.GetTicketRecipients(ticket.ID)
+	if err != nil {
+		return err
+	}
+
+	var to []recipient
+	authorIsContact := comment.AuthorContactID != nil && r.ContactID != nil && *comment.AuthorContactID == *r.ContactID
+	if !comment.Internal && !authorIsContact {
+		to = append(to, recipient{r.ContactName, r.ContactEmail})
+	}
+	authorIsAssignee := comment.AuthorID != nil && r.AssigneeID != nil && *comment.AuthorID == *r.AssigneeID
+	if !authorIsAssignee {
+		to = append(to, recipient{r.AssigneeName, r.AssigneeEmail})
+	}
+	return n.enqueue(EventTicketCommented, ticket, comment.Body, to...)
+}
+
+// TicketResolved tells the requester that their ticket was resolved
+func (n *Notifier) TicketResolved(ticket model.Ticket) error {
+	r, err := n.store.GetTicketRecipients(ticket.ID)
+	if err != nil {
+		return err
+	}
+	return n.enqueue(EventTicketResolved, ticket, "", recipient{r.ContactName, r.ContactEmail})
+}
+
+// PasswordReset sends a user the link to choose a new password
+func (n *Notifier) PasswordReset(user model.User, link string, expiresAt time.Time) error {
+	return n.enqueueAccount(EventPasswordReset, user, link, expiresAt)
+}
+
+// EmailVerification asks a user to confirm their email address
+func (n *Notifier) EmailVerification(user model.User, link string, expiresAt time.Time) error {
+	return n.enqueueAccount(EventEmailVerification, user, link, expiresAt)
+}
+
+func (n *Notifier) enqueueAccount(event string, user model.User, link string, expiresAt time.Time) error {
+	subject, body, err := render(n.lang, event, templateData{
+		Recipient: user.Username,
+		Link:      link,
+		ExpiresAt: expiresAt.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = n.store.EnqueueEmail(model.OutboxEmail{
+		Event:     event,
+		Recipient: user.Email,
+		Subject:   subject,
+		Body:      body,
+	})
+	return err
+}
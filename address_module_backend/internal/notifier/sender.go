@@ -0,0 +1,80 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Sender delivers a single email
+type Sender interface {
+	Send(to, subject, body string) error
+}
+
+// SMTPSender sends through an SMTP server. STARTTLS is used when the server
+// offers it; a local fake server such as Mailpit works without TLS or auth.
+type SMTPSender struct {
+	cfg Config
+}
+
+// NewSMTPSender creates a sender for the configured SMTP server
+func NewSMTPSender(cfg Config) *SMTPSender {
+	return &SMTPSender{cfg: cfg}
+}
+
+// Send implements Sender
+func (s *SMTPSender) Send(to, subject, body string) error {
+	from, err := mail.ParseAddress(s.cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP_FROM: %w", err)
+	}
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+
+	msg, err := buildMessage(from, rcpt, subject, body, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+	addr := net.JoinHostPort(s.cfg.Host, s.cfg.Port)
+	return smtp.SendMail(addr, auth, from.Address, []string{rcpt.Address}, msg)
+}
+
+// buildMessage renders a UTF-8 plain text RFC 5322 message
+func buildMessage(from, to *mail.Address, subject, body string, now time.Time) ([]byte, error) {
+	domain := "localhost"
+	if at := strings.LastIndex(from.Address, "@"); at >= 0 {
+		domain = from.Address[at+1:]
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%d@%s>\r\n", now.UnixNano(), domain)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
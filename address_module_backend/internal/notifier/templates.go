@@ -0,0 +1,176 @@
+package notifier
+
+import (
+	"address_module/internal/model"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Ticket events that trigger notifications
+const (
+	EventTicketCreated   = "ticket_created"
+	EventTicketAssigned  = "ticket_assigned"
+	EventTicketCommented = "ticket_commented"
+	EventTicketResolved  = "ticket_resolved"
+)
+
+// Account events, sent to a user rather than about a ticket
+const (
+	EventPasswordReset     = "password_reset"
+	EventEmailVerification = "email_verification"
+)
+
+// templateData is what the subject and body templates can refer to
+type templateData struct {
+	Token     string // [Ticket#123], keeps replies threaded
+	Ticket    model.Ticket
+	Recipient string
+	Comment   string
+	Link      string    // account events: the reset or verification link
+	ExpiresAt time.Time // account events: when Link stops working
+}
+
+type mailTemplate struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+func mustTemplate(subject, body string) mailTemplate {
+	return mailTemplate{
+		subject: template.Must(template.New("subject").Parse(subject)),
+		body:    template.Must(template.New("body").Parse(strings.TrimLeft(body, "\n"))),
+	}
+}
+
+// templates holds one subject/body pair per language and event
+var templates = map[string]map[string]mailTemplate{
+	"de": {
+		EventTicketCreated: mustTemplate(`{{.Token}} Ihre Anfrage ist eingegangen: {{.Ticket.Title}}`, `
+Hallo {{if .Recipient}}{{.Recipient}}{{else}}zusammen{{end}},
+
+vielen Dank für Ihre Anfrage. Wir haben das Ticket {{.Token}} angelegt und melden uns so bald wie möglich.
+
+Betreff: {{.Ticket.Title}}
+Priorität: {{.Ticket.Priority}}
+
+Bitte behalten Sie bei Antworten die Ticketnummer im Betreff bei.
+`),
+		EventTicketAssigned: mustTemplate(`{{.Token}} Ticket zugewiesen: {{.Ticket.Title}}`, `
+Hallo {{.Recipient}},
+
+Ihnen wurde das Ticket {{.Token}} zugewiesen.
+
+Betreff: {{.Ticket.Title}}
+Priorität: {{.Ticket.Priority}}
+Status: {{.Ticket.Status}}
+{{with .Ticket.ResolutionDueAt}}Zu lösen bis: {{.Format "02.01.2006 15:04"}} (UTC)
+{{end}}
+{{.Ticket.Description}}
+`),
+		EventTicketCommented: mustTemplate(`{{.Token}} Neue Antwort: {{.Ticket.Title}}`, `
+Hallo {{if .Recipient}}{{.Recipient}}{{else}}zusammen{{end}},
+
+zum Ticket {{.Token}} gibt es eine neue Nachricht:
+
+{{.Comment}}
+`),
+		EventTicketResolved: mustTemplate(`{{.Token}} Ticket gelöst: {{.Ticket.Title}}`, `
+Hallo {{if .Recipient}}{{.Recipient}}{{else}}zusammen{{end}},
+
+das Ticket {{.Token}} „{{.Ticket.Title}}" wurde als gelöst markiert.
+
+Falls das Problem weiterhin besteht, antworten Sie einfach auf diese E-Mail.
+`),
+		EventPasswordReset: mustTemplate(`Passwort zurücksetzen`, `
+Hallo {{.Recipient}},
+
+für Ihr Konto wurde ein neues Passwort angefordert. Über diesen Link können Sie es festlegen:
+
+{{.Link}}
+
+Der Link ist einmalig gültig bis {{.ExpiresAt.Format "02.01.2006 15:04"}} (UTC).
+Falls Sie das nicht angefordert haben, können Sie diese E-Mail ignorieren.
+`),
+		EventEmailVerification: mustTemplate(`Bitte bestätigen Sie Ihre E-Mail-Adresse`, `
+Hallo {{.Recipient}},
+
+bitte bestätigen Sie Ihre E-Mail-Adresse über diesen Link:
+
+{{.Link}}
+
+Der Link ist gültig bis {{.ExpiresAt.Format "02.01.2006 15:04"}} (UTC).
+`),
+	},
+	"en": {
+		EventTicketCreated: mustTemplate(`{{.Token}} We received your request: {{.Ticket.Title}}`, `
+Hello {{if .Recipient}}{{.Recipient}}{{else}}there{{end}},
+
+thank you for your request. We opened ticket {{.Token}} and will get back to you as soon as possible.
+
+Subject: {{.Ticket.Title}}
+Priority: {{.Ticket.Priority}}
+
+Please keep the ticket number in the subject when replying.
+`),
+		EventTicketAssigned: mustTemplate(`{{.Token}} Ticket assigned: {{.Ticket.Title}}`, `
+Hello {{.Recipient}},
+
+ticket {{.Token}} has been assigned to you.
+
+Subject: {{.Ticket.Title}}
+Priority: {{.Ticket.Priority}}
+Status: {{.Ticket.Status}}
+{{with .Ticket.ResolutionDueAt}}Resolve by: {{.Format "2006-01-02 15:04"}} (UTC)
+{{end}}
+{{.Ticket.Description}}
+`),
+		EventTicketCommented: mustTemplate(`{{.Token}} New reply: {{.Ticket.Title}}`, `
+Hello {{if .Recipient}}{{.Recipient}}{{else}}there{{end}},
+
+there is a new message on ticket {{.Token}}:
+
+{{.Comment}}
+`),
+		EventTicketResolved: mustTemplate(`{{.Token}} Ticket resolved: {{.Ticket.Title}}`, `
+Hello {{if .Recipient}}{{.Recipient}}{{else}}there{{end}},
+
+ticket {{.Token}} "{{.Ticket.Title}}" has been marked as resolved.
+
+If the problem persists, simply reply to this email.
+`),
+		EventPasswordReset: mustTemplate(`Reset your password`, `
+Hello {{.Recipient}},
+
+a new password was requested for your account. Use this link to choose one:
+
+{{.Link}}
+
+The link works once and expires at {{.ExpiresAt.Format "2006-01-02 15:04"}} (UTC).
+If you did not request this, you can ignore this email.
+`),
+		EventEmailVerification: mustTemplate(`Please confirm your email address`, `
+Hello {{.Recipient}},
+
+please confirm your email address using this link:
+
+{{.Link}}
+
+The link expires at {{.ExpiresAt.Format "2006-01-02 15:04"}} (UTC).
+`),
+	},
+}
+
+// render fills in the subject and body of an event's template
+func render(lang, event string, data templateData) (string, string, error) {
+	tmpl := templates[lang][event]
+
+	var subject, body strings.Builder
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return "", "", err
+	}
+	if err := tmpl.body.Execute(&body, data); err != nil {
+		return "", "", err
+	}
+	return subject.String(), body.String(), nil
+}
@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Config holds the scheduler settings read from the environment
+type Config struct {
+	Enabled            bool          // SCHEDULER_ENABLED, default true
+	EscalationInterval time.Duration // SLA_ESCALATION_INTERVAL, default 5m
+	EscalationLead     time.Duration // SLA_ESCALATION_LEAD, default 1h
+	WarrantyInterval   time.Duration // WARRANTY_SCAN_INTERVAL, default 6h
+	WarrantyNoticeDays int           // WARRANTY_NOTICE_DAYS, default 30
+	PurgeInterval      time.Duration // SOFT_DELETE_PURGE_INTERVAL, default 24h
+	RetentionDays      int           // SOFT_DELETE_RETENTION_DAYS, default 30; 0 keeps deleted rows forever
+	TokenCleanupEvery  time.Duration // TOKEN_CLEANUP_INTERVAL, default 1h
+}
+
+// ConfigFromEnv reads the scheduler configuration, falling back to defaults for unset or invalid values
+func ConfigFromEnv() Config {
+	return Config{
+		Enabled:            envBool("SCHEDULER_ENABLED", true),
+		EscalationInterval: envDuration("SLA_ESCALATION_INTERVAL", 5*time.Minute),
+		EscalationLead:     envDuration("SLA_ESCALATION_LEAD", time.Hour),
+		WarrantyInterval:   envDuration("WARRANTY_SCAN_INTERVAL", 6*time.Hour),
+		WarrantyNoticeDays: envInt("WARRANTY_NOTICE_DAYS", 30),
+		PurgeInterval:      envDuration("SOFT_DELETE_PURGE_INTERVAL", 24*time.Hour),
+		RetentionDays:      envInt("SOFT_DELETE_RETENTION_DAYS", 30),
+		TokenCleanupEvery:  envDuration("TOKEN_CLEANUP_INTERVAL", time.Hour),
+	}
+}
+
+func envBool(name string, def bool) bool {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Warnf("Invalid %s=%q, using %v", name, v, def)
+		return def
+	}
+	return b
+}
+
+func envDuration(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Warnf("Invalid %s=%q, using %s", name, v, def)
+		return def
+	}
+	return d
+}
+
+func envInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Warnf("Invalid %s=%q, using %d", name, v, def)
+		return def
+	}
+	return n
+}
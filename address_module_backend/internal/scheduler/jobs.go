@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"address_module/internal/tools"
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EscalationStore marks tickets whose SLA deadline falls before `until` as escalated
+type EscalationStore interface {
+	EscalateTickets(now, until time.Time) ([]int64, error)
+}
+
+// SLAEscalationJob escalates open tickets that are overdue or within Lead of a breach
+type SLAEscalationJob struct {
+	Store EscalationStore
+	Lead  time.Duration
+	Every time.Duration
+}
+
+func (j *SLAEscalationJob) Name() string            { return "sla_escalation" }
+func (j *SLAEscalationJob) Interval() time.Duration { return j.Every }
+
+func (j *SLAEscalationJob) Run(ctx context.Context, now time.Time) error {
+	ids, err := j.Store.EscalateTickets(now, now.Add(j.Lead))
+	if err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		log.WithField("tickets", ids).Info("Escalated tickets nearing SLA breach")
+	}
+	return nil
+}
+
+// WarrantyDeviceSource lists devices whose warranty ends in a date range
+type WarrantyDeviceSource interface {
+	GetDevicesWithWarrantyEnding(from, to time.Time) ([]tools.DeviceParams, error)
+}
+
+// WarrantyTicketStore opens at most one ticket per device and warranty date
+type WarrantyTicketStore interface {
+	CreateWarrantyTicket(device tools.DeviceParams) (int64, error)
+}
+
+// WarrantyExpiryJob opens a ticket NoticeDays before a device's warranty runs out
+type WarrantyExpiryJob struct {
+	Devices    WarrantyDeviceSource
+	Tickets    WarrantyTicketStore
+	NoticeDays int
+	Every      time.Duration
+}
+
+func (j *WarrantyExpiryJob) Name() string            { return "warranty_expiry" }
+func (j *WarrantyExpiryJob) Interval() time.Duration { return j.Every }
+
+func (j *WarrantyExpiryJob) Run(ctx context.Context, now time.Time) error {
+	devices, err := j.Devices.GetDevicesWithWarrantyEnding(now, now.AddDate(0, 0, j.NoticeDays))
+	if err != nil {
+		return err
+	}
+
+	for _, d := range devices {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		ticketID, err := j.Tickets.CreateWarrantyTicket(d)
+		if err != nil {
+			return err
+		}
+		if ticketID != 0 {
+			log.WithFields(log.Fields{"device_id": d.ID, "ticket_id": ticketID}).Info("Opened warranty expiry ticket")
+		}
+	}
+	return nil
+}
+
+// TokenPurgeStore removes refresh tokens and revocation entries past their expiry
+type TokenPurgeStore interface {
+	PurgeExpiredTokens(now time.Time) (int64, error)
+}
+
+// TokenCleanupJob keeps the session tables from growing without bound
+type TokenCleanupJob struct {
+	Store TokenPurgeStore
+	Every time.Duration
+}
+
+func (j *TokenCleanupJob) Name() string            { return "token_cleanup" }
+func (j *TokenCleanupJob) Interval() time.Duration { return j.Every }
+
+func (j *TokenCleanupJob) Run(ctx context.Context, now time.Time) error {
+	_, err := j.Store.PurgeExpiredTokens(now)
+	return err
+}
+
+// PurgeStore permanently removes rows soft-deleted before a point in time
+type PurgeStore interface {
+	PurgeDeleted(before time.Time) (int64, error)
+}
+
+// SoftDeletePurgeJob removes soft-deleted rows once RetentionDays have passed
+type SoftDeletePurgeJob struct {
+	Stores        []PurgeStore
+	RetentionDays int
+	Every         time.Duration
+}
+
+func (j *SoftDeletePurgeJob) Name() string            { return "soft_delete_purge" }
+func (j *SoftDeletePurgeJob) Interval() time.Duration { return j.Every }
+
+func (j *SoftDeletePurgeJob) Run(ctx context.Context, now time.Time) error {
+	before := now.AddDate(0, 0, -j.RetentionDays)
+	for _, store := range j.Stores {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// Each store logs what it removed
+		if _, err := store.PurgeDeleted(before); err != nil {
+			return err
+		}
+	}
+	return nil
+}
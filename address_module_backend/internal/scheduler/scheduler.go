@@ -0,0 +1,109 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Clock abstracts time so jobs and the run loop can be driven by a fake in tests
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SystemClock is the wall clock
+var SystemClock Clock = systemClock{}
+
+// Job is a unit of periodic background work. Run must be idempotent: the
+// scheduler may run a job again after a crash or restart at any point.
+type Job interface {
+	Name() string
+	Interval() time.Duration
+	Run(ctx context.Context, now time.Time) error
+}
+
+// RunRecorder persists the outcome of each job run
+type RunRecorder interface {
+	RecordSchedulerRun(jobName string, startedAt time.Time, duration time.Duration, runErr error) error
+}
+
+// Scheduler runs registered jobs in their own goroutines until its context is cancelled
+type Scheduler struct {
+	clock    Clock
+	recorder RunRecorder
+	jobs     []Job
+	wg       sync.WaitGroup
+}
+
+// New creates a scheduler; recorder may be nil if runs should not be persisted
+func New(clock Clock, recorder RunRecorder) *Scheduler {
+	if clock == nil {
+		clock = SystemClock
+	}
+	return &Scheduler{clock: clock, recorder: recorder}
+}
+
+// Register adds a job; it must be called before Start
+func (s *Scheduler) Register(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every job once right away and then after each interval.
+// Cancel ctx and call Wait to shut down; a running job is allowed to finish.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+	log.Infof("Scheduler started with %d job(s)", len(s.jobs))
+}
+
+// Wait blocks until all job loops have returned
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+	for {
+		s.RunOnce(ctx, job)
+		select {
+		case <-ctx.Done():
+			log.Infof("Scheduler job %s stopped", job.Name())
+			return
+		case <-s.clock.After(job.Interval()):
+		}
+	}
+}
+
+// RunOnce executes a job a single time, recovering from panics and recording the result
+func (s *Scheduler) RunOnce(ctx context.Context, job Job) (err error) {
+	started := s.clock.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+		duration := s.clock.Now().Sub(started)
+		if err != nil {
+			log.Errorf("Scheduler job %s failed: %v", job.Name(), err)
+		} else {
+			log.Debugf("Scheduler job %s finished in %s", job.Name(), duration)
+		}
+		if s.recorder != nil {
+			if recErr := s.recorder.RecordSchedulerRun(job.Name(), started, duration, err); recErr != nil {
+				log.Errorf("Failed to record run of %s: %v", job.Name(), recErr)
+			}
+		}
+	}()
+
+	return job.Run(ctx, started)
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FSStore stores blobs on the local filesystem as <root>/ab/cd/abcd...
+type FSStore struct {
+	root string
+}
+
+// NewFSStore creates the root directory if needed
+func NewFSStore(root string) (*FSStore, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("could not create attachment storage %s: %w", root, err)
+	}
+	return &FSStore{root: root}, nil
+}
+
+func (s *FSStore) path(sum string) (string, error) {
+	if len(sum) != sha256.Size*2 {
+		return "", ErrNotFound
+	}
+	if _, err := hex.DecodeString(sum); err != nil {
+		return "", ErrNotFound
+	}
+	return filepath.Join(s.root, sum[:2], sum[2:4], sum), nil
+}
+
+// Put implements Store. Content is written to a temp file first and only
+// moved into place once complete, so readers never see partial blobs.
+func (s *FSStore) Put(r io.Reader) (string, int64, error) {
+	tmp, err := os.CreateTemp(s.root, "upload-*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	dest, _ := s.path(sum)
+	if _, err := os.Stat(dest); err == nil {
+		return sum, size, nil // already stored
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o750); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return "", 0, err
+	}
+	return sum, size, nil
+}
+
+// Open implements Store
+func (s *FSStore) Open(sum string) (io.ReadCloser, error) {
+	p, err := s.path(sum)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete implements Store
+func (s *FSStore) Delete(sum string) error {
+	p, err := s.path(sum)
+	if err != nil {
+		return nil
+	}
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrNotFound is returned when no blob exists for a hash
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps file contents addressed by their SHA-256 hash, so identical
+// uploads are stored once no matter how many tickets reference them
+type Store interface {
+	// Put stores the content of r and returns its hex SHA-256 and size
+	Put(r io.Reader) (sum string, size int64, err error)
+	// Open returns the content stored under sum, or ErrNotFound
+	Open(sum string) (io.ReadCloser, error)
+	// Delete removes the content stored under sum; missing blobs are not an error
+	Delete(sum string) error
+}
+
+// Config holds the attachment settings read from the environment
+type Config struct {
+	Path         string   // ATTACHMENT_STORAGE_PATH, default /data/attachments
+	MaxBytes     int64    // ATTACHMENT_MAX_BYTES per file, default 20 MiB
+	AllowedTypes []string // ATTACHMENT_ALLOWED_TYPES, comma separated; "image/*" matches a whole family
+}
+
+var defaultAllowedTypes = []string{
+	"image/*",
+	"text/*",
+	"application/pdf",
+	"application/zip",
+	"application/x-gzip",
+	"application/json",
+	"application/xml",
+}
+
+// ConfigFromEnv reads the attachment configuration
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Path:         os.Getenv("ATTACHMENT_STORAGE_PATH"),
+		MaxBytes:     20 << 20,
+		AllowedTypes: defaultAllowedTypes,
+	}
+	if cfg.Path == "" {
+		cfg.Path = "/data/attachments"
+	}
+	if v := os.Getenv("ATTACHMENT_MAX_BYTES"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			cfg.MaxBytes = n
+		} else {
+			log.Warnf("Invalid ATTACHMENT_MAX_BYTES=%q, using %d", v, cfg.MaxBytes)
+		}
+	}
+	if v := os.Getenv("ATTACHMENT_ALLOWED_TYPES"); v != "" {
+		cfg.AllowedTypes = nil
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+				cfg.AllowedTypes = append(cfg.AllowedTypes, t)
+			}
+		}
+	}
+	return cfg
+}
+
+// TypeAllowed reports whether a media type (without parameters) may be uploaded
+func (c Config) TypeAllowed(mediaType string) bool {
+	mediaType = strings.ToLower(mediaType)
+	for _, allowed := range c.AllowedTypes {
+		if allowed == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
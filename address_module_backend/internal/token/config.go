@@ -0,0 +1,115 @@
+package token
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+// KeyringFromEnv builds the keyring from the environment.
+//
+// JWT_KEYS lists the keys as comma-separated kid:alg:source entries, where alg
+// is HS256, RS256 or EdDSA and source is file:<path> or env:<VAR>. HS256
+// sources hold the raw secret; RS256/EdDSA sources hold a PEM private key, or
+// a PEM public key for a key that only verifies (e.g. one being retired).
+// JWT_ACTIVE_KID picks the signing key and defaults to the first entry.
+//
+// Without JWT_KEYS, JWT_SECRET is used as a single HS256 key "default". If
+// neither is set a random secret is generated, so tokens do not survive a restart.
+func KeyringFromEnv() (*Keyring, error) {
+	spec := strings.TrimSpace(os.Getenv("JWT_KEYS"))
+	if spec == "" {
+		secret := []byte(os.Getenv("JWT_SECRET"))
+		if len(secret) == 0 {
+			log.Warn("Neither JWT_KEYS nor JWT_SECRET is set, using a random secret; tokens are invalidated on restart")
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return nil, err
+			}
+		}
+		key, err := NewKey("default", AlgHS256, secret)
+		if err != nil {
+			return nil, err
+		}
+		return NewKeyring(key.ID, key)
+	}
+
+	var keys []*Key
+	for _, entry := range strings.Split(spec, ",") {
+		key, err := parseKeySpec(strings.TrimSpace(entry))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	active := os.Getenv("JWT_ACTIVE_KID")
+	if active == "" {
+		active = keys[0].ID
+	}
+	kr, err := NewKeyring(active, keys...)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("🔑 Loaded %d JWT keys, signing with %q", len(keys), active)
+	return kr, nil
+}
+
+// parseKeySpec loads one kid:alg:source entry of JWT_KEYS
+func parseKeySpec(entry string) (*Key, error) {
+	parts := strings.SplitN(entry, ":", 4)
+	if len(parts) != 4 || parts[0] == "" {
+		return nil, fmt.Errorf("invalid JWT_KEYS entry %q, expected kid:alg:file:<path> or kid:alg:env:<VAR>", entry)
+	}
+	kid, alg, kind, ref := parts[0], parts[1], parts[2], parts[3]
+
+	var raw []byte
+	switch kind {
+	case "file":
+		b, err := os.ReadFile(ref)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", kid, err)
+		}
+		raw = b
+	case "env":
+		raw = []byte(os.Getenv(ref))
+		if len(raw) == 0 {
+			return nil, fmt.Errorf("key %s: %s is empty", kid, ref)
+		}
+	default:
+		return nil, fmt.Errorf("key %s: unknown source %q", kid, kind)
+	}
+
+	material, err := parseKeyMaterial(alg, raw)
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", kid, err)
+	}
+	return NewKey(kid, alg, material)
+}
+
+// parseKeyMaterial decodes a secret or a PEM key, trying the private form first
+func parseKeyMaterial(alg string, raw []byte) (interface{}, error) {
+	switch alg {
+	case AlgHS256:
+		secret := []byte(strings.TrimSpace(string(raw)))
+		if len(secret) < 32 {
+			log.Warnf("HS256 secret is shorter than 32 bytes")
+		}
+		return secret, nil
+	case AlgRS256:
+		if k, err := jwt.ParseRSAPrivateKeyFromPEM(raw); err == nil {
+			return k, nil
+		}
+		return jwt.ParseRSAPublicKeyFromPEM(raw)
+	case AlgEdDSA:
+		if k, err := jwt.ParseEdPrivateKeyFromPEM(raw); err == nil {
+			return k, nil
+		}
+		return jwt.ParseEdPublicKeyFromPEM(raw)
+	}
+	return nil, fmt.Errorf("unsupported algorithm %q", alg)
+}
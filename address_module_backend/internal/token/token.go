@@ -0,0 +1,151 @@
+// Package token signs and verifies the JWTs issued by the API. Every token
+// carries a kid header naming the key it was signed with, so old keys can
+// keep verifying tokens while a new key signs fresh ones.
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported algorithms
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var (
+	// ErrUnknownKey is returned for tokens whose kid is not in the keyring
+	ErrUnknownKey = errors.New("unknown signing key")
+	// ErrNoSigningKey is returned when the active key can only verify
+	ErrNoSigningKey = errors.New("active key has no private part")
+)
+
+// Key is one entry of the keyring. Public-only keys verify tokens but never sign.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	sign   interface{} // []byte, *rsa.PrivateKey or ed25519.PrivateKey; nil for public-only keys
+	verify interface{} // []byte, *rsa.PublicKey or ed25519.PublicKey
+}
+
+// NewKey builds a key from its material: a shared secret for HS256, a
+// private key (sign and verify) or public key (verify only) for RS256/EdDSA
+func NewKey(id, alg string, material interface{}) (*Key, error) {
+	k := &Key{ID: id}
+	switch alg {
+	case AlgHS256:
+		secret, ok := material.([]byte)
+		if !ok || len(secret) == 0 {
+			return nil, fmt.Errorf("key %s: HS256 needs a non-empty secret", id)
+		}
+		k.Method, k.sign, k.verify = jwt.SigningMethodHS256, secret, secret
+	case AlgRS256:
+		k.Method = jwt.SigningMethodRS256
+		switch m := material.(type) {
+		case *rsa.PrivateKey:
+			k.sign, k.verify = m, &m.PublicKey
+		case *rsa.PublicKey:
+			k.verify = m
+		default:
+			return nil, fmt.Errorf("key %s: RS256 needs an RSA key", id)
+		}
+	case AlgEdDSA:
+		k.Method = jwt.SigningMethodEdDSA
+		switch m := material.(type) {
+		case ed25519.PrivateKey:
+			k.sign, k.verify = m, m.Public()
+		case ed25519.PublicKey:
+			k.verify = m
+		default:
+			return nil, fmt.Errorf("key %s: EdDSA needs an Ed25519 key", id)
+		}
+	default:
+		return nil, fmt.Errorf("key %s: unsupported algorithm %q", id, alg)
+	}
+	return k, nil
+}
+
+// CanSign reports whether the key has a private part
+func (k *Key) CanSign() bool {
+	return k.sign != nil
+}
+
+// PublicKey returns the verification key of an asymmetric key, or nil for HS256
+func (k *Key) PublicKey() crypto.PublicKey {
+	if _, ok := k.verify.([]byte); ok {
+		return nil
+	}
+	return k.verify
+}
+
+// Keyring holds every key that may verify tokens and the one that signs new ones
+type Keyring struct {
+	active *Key
+	keys   map[string]*Key
+	algs   []string
+}
+
+// NewKeyring returns a keyring signing with the key named activeID
+func NewKeyring(activeID string, keys ...*Key) (*Keyring, error) {
+	kr := &Keyring{keys: make(map[string]*Key, len(keys))}
+	seen := map[string]bool{}
+	for _, k := range keys {
+		if _, dup := kr.keys[k.ID]; dup {
+			return nil, fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		kr.keys[k.ID] = k
+		if alg := k.Method.Alg(); !seen[alg] {
+			seen[alg] = true
+			kr.algs = append(kr.algs, alg)
+		}
+	}
+
+	kr.active = kr.keys[activeID]
+	if kr.active == nil {
+		return nil, fmt.Errorf("active key %q: %w", activeID, ErrUnknownKey)
+	}
+	if !kr.active.CanSign() {
+		return nil, fmt.Errorf("active key %q: %w", activeID, ErrNoSigningKey)
+	}
+	return kr, nil
+}
+
+// ActiveKeyID names the key new tokens are signed with
+func (kr *Keyring) ActiveKeyID() string {
+	return kr.active.ID
+}
+
+// Sign signs claims with the active key and sets the kid header
+func (kr *Keyring) Sign(claims jwt.Claims) (string, error) {
+	t := jwt.NewWithClaims(kr.active.Method, claims)
+	t.Header["kid"] = kr.active.ID
+	return t.SignedString(kr.active.sign)
+}
+
+// Parse verifies a token against the key named in its kid header and returns its claims.
+// The token's alg must match the key's algorithm.
+func (kr *Keyring) Parse(tokenStr string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenStr, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := kr.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return key.verify, nil
+	}, jwt.WithValidMethods(kr.algs), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"address_module/internal/model"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// InsertAuditEntry appends an entry to the audit log. There is deliberately no
+// update or delete counterpart; the table rejects both (see SetupAuditLogTable).
+func (db *MySQLDB) InsertAuditEntry(entry model.AuditEntry) (int64, error) {
+	result, err := db.DB.Exec(`
+	INSERT INTO audit_log (user_id, action, entity_type, entity_id, changes)
+	VALUES (?, ?, ?, ?, ?)`,
+		entry.UserID, entry.Action, entry.EntityType, entry.EntityID, string(entry.Changes))
+	if err != nil {
+		log.Error("Failed to insert audit entry: ", err)
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetAuditEntries returns the newest audit entries matching the filter
+func (db *MySQLDB) GetAuditEntries(filter model.AuditFilter) ([]model.AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.EntityType != "" {
+		conditions = append(conditions, "entity_type = ?")
+		args = append(args, filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		conditions = append(conditions, "entity_id = ?")
+		args = append(args, *filter.EntityID)
+	}
+	if filter.UserID != nil {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, *filter.UserID)
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From.UTC())
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To.UTC())
+	}
+
+	query := `SELECT id, created_at, user_id, action, entity_type, entity_id, changes FROM audit_log`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, filter.Limit)
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		log.Error("Failed to query audit log: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []model.AuditEntry{}
+	for rows.Next() {
+		var e model.AuditEntry
+		var changes []byte
+		if err := rows.Scan(&e.ID, &e.CreatedAt, &e.UserID, &e.Action, &e.EntityType, &e.EntityID, &changes); err != nil {
+			log.Error("Failed to scan audit entry: ", err)
+			return nil, err
+		}
+		e.Changes = changes
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"address_module/internal/model"
+	"fmt"
+	"time"
+	_ "time/tzdata" // the alpine runtime image ships without zoneinfo
+)
+
+// BusinessCalendar answers "when is N working minutes after t" for an SLA policy
+type BusinessCalendar struct {
+	loc      *time.Location
+	startMin int // minutes after midnight
+	endMin   int
+	workDays map[time.Weekday]bool
+	holidays map[string]bool // YYYY-MM-DD in the calendar's time zone
+}
+
+// NewBusinessCalendar builds the calendar of a validated SLA policy
+func NewBusinessCalendar(policy *model.SLAPolicy) (*BusinessCalendar, error) {
+	loc, err := time.LoadLocation(policy.Timezone)
+	if err != nil {
+		return nil, err
+	}
+	start, err := time.Parse("15:04", policy.BusinessHoursStart)
+	if err != nil {
+		return nil, fmt.Errorf("invalid business_hours_start: %w", err)
+	}
+	end, err := time.Parse("15:04", policy.BusinessHoursEnd)
+	if err != nil {
+		return nil, fmt.Errorf("invalid business_hours_end: %w", err)
+	}
+	if len(policy.WorkDays) == 0 {
+		return nil, fmt.Errorf("policy %q has no work days", policy.Name)
+	}
+
+	c := &BusinessCalendar{
+		loc:      loc,
+		startMin: start.Hour()*60 + start.Minute(),
+		endMin:   end.Hour()*60 + end.Minute(),
+		workDays: make(map[time.Weekday]bool),
+		holidays: make(map[string]bool),
+	}
+	for _, d := range policy.WorkDays {
+		c.workDays[time.Weekday(d)] = true
+	}
+	for _, h := range policy.Holidays {
+		c.holidays[h.Date] = true
+	}
+	return c, nil
+}
+
+// IsWorkDay reports whether the calendar day of t is neither a weekend nor a holiday
+func (c *BusinessCalendar) IsWorkDay(t time.Time) bool {
+	t = t.In(c.loc)
+	return c.workDays[t.Weekday()] && !c.holidays[t.Format("2006-01-02")]
+}
+
+func (c *BusinessCalendar) at(day time.Time, minutes int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, c.loc)
+}
+
+// AddBusinessMinutes returns the point in time that lies the given number of
+// business minutes after start. Time outside business hours, on non-work days
+// and on holidays does not count.
+func (c *BusinessCalendar) AddBusinessMinutes(start time.Time, minutes int) time.Time {
+	t := start.In(c.loc)
+	remaining := time.Duration(minutes) * time.Minute
+
+	// Ten years of days is far beyond any sane SLA and guards against calendars
+	// that never open (e.g. every work day listed as a holiday).
+	for i := 0; remaining > 0 && i < 3660; i++ {
+		open := c.at(t, c.startMin)
+		closing := c.at(t, c.endMin)
+		nextDay := time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.loc)
+
+		if !c.IsWorkDay(t) || !t.Before(closing) {
+			t = nextDay
+			continue
+		}
+		if t.Before(open) {
+			t = open
+		}
+
+		available := closing.Sub(t)
+		if remaining <= available {
+			return t.Add(remaining)
+		}
+		remaining -= available
+		t = nextDay
+	}
+	return t
+}
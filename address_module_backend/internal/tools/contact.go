@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrFirmNotFound is returned when a contact is linked to a firm that does not exist
+var ErrFirmNotFound = errors.New("firm not found")
+
+// GetContactByID fetches a single contact; soft-deleted contacts only with includeDeleted
+func (db *MySQLDB) GetContactByID(id int64, includeDeleted bool) (*ContactParams, error) {
+	query := `
+	SELECT id, COALESCE(anrede, ''), vorname, nachname, COALESCE(position, ''),
+	       COALESCE(telefon, ''), COALESCE(mobil, ''), email, COALESCE(abteilung, ''),
+	       COALESCE(CAST(geburtstag AS CHAR), ''), COALESCE(bemerkung, ''), kontotyp, deleted_at, deleted_by
+	FROM contacts
+	WHERE id = ?`
+	if !includeDeleted {
+		query += ` AND deleted_at IS NULL`
+	}
+
+	var contact ContactParams
+	err := db.DB.QueryRow(query, id).Scan(
+		&contact.ID, &contact.Anrede, &contact.Vorname, &contact.Nachname, &contact.Position,
+		&contact.Telefon, &contact.Mobil, &contact.Email, &contact.Abteilung,
+		&contact.Geburtstag, &contact.Bemerkung, &contact.Kontotyp, &contact.DeletedAt, &contact.DeletedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+// UpdateContact replaces the fields of a contact and adds or removes firm
+// relations in one transaction. Returns sql.ErrNoRows if the contact does not
+// exist and ErrFirmNotFound if a firm to add does not exist.
+func (db *MySQLDB) UpdateContact(contact ContactParams, addFirms, removeFirms []int64) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return err
+	}
+
+	var exists int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM contacts WHERE id = ? AND deleted_at IS NULL`, contact.ID).Scan(&exists); err != nil || exists == 0 {
+		tx.Rollback()
+		return sql.ErrNoRows
+	}
+
+	var geburtstag interface{}
+	if contact.Geburtstag != "" {
+		geburtstag = contact.Geburtstag
+	}
+
+	_, err = tx.Exec(`
+	UPDATE contacts
+	SET anrede = ?, vorname = ?, nachname = ?, position = ?, telefon = ?, mobil = ?,
+	    email = ?, abteilung = ?, geburtstag = ?, bemerkung = ?, kontotyp = ?
+	WHERE id = ?`,
+		contact.Anrede, contact.Vorname, contact.Nachname, contact.Position, contact.Telefon, contact.Mobil,
+		contact.Email, contact.Abteilung, geburtstag, contact.Bemerkung, contact.Kontotyp, contact.ID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to update contact: ", err)
+		return err
+	}
+
+	for _, firmID := range removeFirms {
+		if _, err := tx.Exec(`DELETE FROM firms_contacts WHERE firma_id = ? AND contact_id = ?`, firmID, contact.ID); err != nil {
+			tx.Rollback()
+			log.Error("Failed to remove relationship with firm ID ", firmID, ": ", err)
+			return err
+		}
+	}
+
+	for _, firmID := range addFirms {
+		var firmExists int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM firms WHERE id = ? AND deleted_at IS NULL`, firmID).Scan(&firmExists); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if firmExists == 0 {
+			tx.Rollback()
+			return fmt.Errorf("%w: %d", ErrFirmNotFound, firmID)
+		}
+		// Existing relations keep their beziehung and hauptansprechpartner
+		if _, err := tx.Exec(`INSERT IGNORE INTO firms_contacts (firma_id, contact_id) VALUES (?, ?)`, firmID, contact.ID); err != nil {
+			tx.Rollback()
+			log.Error("Failed to create relationship with firm ID ", firmID, ": ", err)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"contact_id":    contact.ID,
+		"added_firms":   addFirms,
+		"removed_firms": removeFirms,
+	}).Info("Contact updated successfully")
+	return nil
+}
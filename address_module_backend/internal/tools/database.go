@@ -19,7 +19,7 @@ type DatabaseInterface interface {
 	InsertFirmWithContact(firm FirmParams, contactID int64) (int64, error)
 	InsertFirmWithContacts(firm FirmParams, contactIDs []int64) (int64, error)
 	InsertContactWithFirms(contact ContactParams, firmIDs []int64) (int64, error)
-	CreateContactFirmRelationship(contactID int64, firmID int64) error
+	CreateContactFirmRelationship(contactID int64, firmID int64, rel FirmContactRelation) error
 	GetUserLoginDetails(username string) *LoginDetails
 	GetUserCoins(username string) *CoinDetails
 	GetFirmsByContactID(contactID int64) ([]FirmParams, error)
@@ -34,7 +34,8 @@ type DatabaseInterface interface {
 
 // FirmParams struct matching the MySQL table
 type FirmParams struct {
-	ID        int64 // Added ID field
+	ID        int64               // Added ID field
+	Relation  FirmContactRelation // Only filled when the firm was loaded through a contact
 	Anrede    string
 	Name1     string
 	Name2     string
@@ -51,6 +52,8 @@ type FirmParams struct {
 	Gesperrt  bool
 	Bemerkung string
 	FirmaTyp  string
+	DeletedAt *time.Time
+	DeletedBy *int64
 }
 
 // LoginDetails struct
@@ -139,6 +142,7 @@ func NewDatabase(maxRetries int, delay time.Duration) (*MySQLDB, error) {
 		err = sqlDB.Ping()
 		if err == nil {
 			log.Infof("Successfully connected to MySQL database on attempt %d", attempt)
+			PoolConfigFromEnv("MYSQL").Apply(sqlDB)
 			return &MySQLDB{DB: sqlDB}, nil
 		}
 
@@ -301,18 +305,44 @@ func (db *MySQLDB) InsertFirmWithContacts(firm FirmParams, contactIDs []int64) (
 	return firmID, nil
 }
 
-// CreateContactFirmRelationship creates a relationship between a contact and a firm
-func (db *MySQLDB) CreateContactFirmRelationship(contactID int64, firmID int64) error {
+// FirmContactRelation is the metadata stored on a firms_contacts row
+type FirmContactRelation struct {
+	Beziehung            string
+	Hauptansprechpartner bool
+}
+
+// CreateContactFirmRelationship creates a relationship between a contact and a firm.
+// Making the contact the primary contact demotes the firm's previous one.
+func (db *MySQLDB) CreateContactFirmRelationship(contactID int64, firmID int64, rel FirmContactRelation) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return err
+	}
+
+	if rel.Hauptansprechpartner {
+		if err := clearPrimaryContact(tx, firmID, contactID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
 	query := `
-	INSERT INTO firms_contacts (firma_id, contact_id) 
-	VALUES (?, ?)`
+	INSERT INTO firms_contacts (firma_id, contact_id, beziehung, hauptansprechpartner) 
+	VALUES (?, ?, ?, ?)`
 
-	_, err := db.DB.Exec(query, firmID, contactID)
+	_, err = tx.Exec(query, firmID, contactID, rel.Beziehung, rel.Hauptansprechpartner)
 	if err != nil {
+		tx.Rollback()
 		log.Error("Failed to create relationship: ", err)
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
 	log.WithFields(log.Fields{
 		"contact_id": contactID,
 		"firm_id":    firmID,
@@ -321,9 +351,71 @@ func (db *MySQLDB) CreateContactFirmRelationship(contactID int64, firmID int64)
 	return nil
 }
 
+// UpdateContactFirmRelationship sets the metadata of an existing relationship;
+// sql.ErrNoRows if the contact is not linked to the firm.
+// Making the contact the primary contact demotes the firm's previous one.
+func (db *MySQLDB) UpdateContactFirmRelationship(contactID int64, firmID int64, rel FirmContactRelation) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return err
+	}
+
+	var relationID int64
+	err = tx.QueryRow(`SELECT id FROM firms_contacts WHERE firma_id = ? AND contact_id = ? FOR UPDATE`, firmID, contactID).Scan(&relationID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if rel.Hauptansprechpartner {
+		if err := clearPrimaryContact(tx, firmID, contactID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE firms_contacts SET beziehung = ?, hauptansprechpartner = ? WHERE id = ?`,
+		rel.Beziehung, rel.Hauptansprechpartner, relationID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to update relationship: ", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"contact_id": contactID,
+		"firm_id":    firmID,
+	}).Info("Relationship updated successfully")
+
+	return nil
+}
+
+// clearPrimaryContact removes the primary flag from every other contact of a
+// firm. The firm row is locked so concurrent requests cannot both promote.
+func clearPrimaryContact(tx *sql.Tx, firmID, exceptContactID int64) error {
+	var id int64
+	if err := tx.QueryRow(`SELECT id FROM firms WHERE id = ? FOR UPDATE`, firmID).Scan(&id); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+	UPDATE firms_contacts SET hauptansprechpartner = FALSE
+	WHERE firma_id = ? AND contact_id <> ? AND hauptansprechpartner`, firmID, exceptContactID)
+	if err != nil {
+		log.Error("Failed to clear primary contact: ", err)
+	}
+	return err
+}
+
 // ContactParams struct matching the MySQL table
 type ContactParams struct {
-	ID         int64 // Added ID field
+	ID         int64               // Added ID field
+	Relation   FirmContactRelation // Only filled when the contact was loaded through a firm
 	Anrede     string
 	Vorname    string
 	Nachname   string
@@ -335,6 +427,8 @@ type ContactParams struct {
 	Geburtstag string // Store as string in YYYY-MM-DD format
 	Bemerkung  string
 	Kontotyp   string
+	DeletedAt  *time.Time
+	DeletedBy  *int64
 }
 
 // InsertContact inserts contact data into MySQL and creates relationship with firm
@@ -463,12 +557,13 @@ func (db *MySQLDB) InsertContactWithFirms(contact ContactParams, firmIDs []int64
 // GetFirmsByContactID retrieves all firms associated with a specific contact
 func (db *MySQLDB) GetFirmsByContactID(contactID int64) ([]FirmParams, error) {
 	query := `
-	SELECT f.id, f.anrede, f.name_1, f.name_2, f.name_3, f.straße, f.land, 
-	       f.plz, f.ort, f.telefon, f.email, f.website, f.kunde, 
-	       f.lieferant, f.gesperrt, f.bemerkung, f.firma_typ
+	SELECT f.id, f.anrede, f.name_1, COALESCE(f.name_2, ''), COALESCE(f.name_3, ''), COALESCE(f.straße, ''), COALESCE(f.land, ''),
+	       f.plz, f.ort, f.telefon, f.email, COALESCE(f.website, ''), f.kunde,
+	       f.lieferant, f.gesperrt, COALESCE(f.bemerkung, ''), COALESCE(f.firma_typ, ''),
+	       COALESCE(fc.beziehung, ''), COALESCE(fc.hauptansprechpartner, FALSE)
 	FROM firms f
 	JOIN firms_contacts fc ON f.id = fc.firma_id
-	WHERE fc.contact_id = ?`
+	WHERE fc.contact_id = ? AND f.deleted_at IS NULL`
 
 	rows, err := db.DB.Query(query, contactID)
 	if err != nil {
@@ -485,6 +580,7 @@ func (db *MySQLDB) GetFirmsByContactID(contactID int64) ([]FirmParams, error) {
 			&firm.Straße, &firm.Land, &firm.PLZ, &firm.Ort, &firm.Telefon,
 			&firm.Email, &firm.Website, &firm.Kunde, &firm.Lieferant,
 			&firm.Gesperrt, &firm.Bemerkung, &firm.FirmaTyp,
+			&firm.Relation.Beziehung, &firm.Relation.Hauptansprechpartner,
 		)
 		if err != nil {
 			log.Error("Failed to scan firm row: ", err)
@@ -508,6 +604,7 @@ func (db *MySQLDB) GetAllFirms() ([]FirmParams, error) {
 	       plz, ort, telefon, email, website, kunde, 
 	       lieferant, gesperrt, bemerkung, firma_typ
 	FROM firms
+	WHERE deleted_at IS NULL
 	ORDER BY id DESC`
 
 	rows, err := db.DB.Query(query)
@@ -547,6 +644,7 @@ func (db *MySQLDB) GetAllContacts() ([]ContactParams, error) {
 	SELECT id, anrede, vorname, nachname, position, telefon, mobil, 
 	       email, abteilung, geburtstag, bemerkung, kontotyp
 	FROM contacts
+	WHERE deleted_at IS NULL
 	ORDER BY id DESC`
 
 	rows, err := db.DB.Query(query)
@@ -581,10 +679,10 @@ func (db *MySQLDB) GetAllContacts() ([]ContactParams, error) {
 
 func (db *MySQLDB) InsertUser(user model.User) (int64, error) {
 	query := `
-	INSERT INTO users (username, email, hashed_password, created_by, last_login)
-	VALUES (?, ?, ?, ?, ?)`
+	INSERT INTO users (username, email, hashed_password, created_by, last_login, email_verified_at)
+	VALUES (?, ?, ?, ?, ?, ?)`
 
-	result, err := db.DB.Exec(query, user.Username, user.Email, user.HashedPassword, user.CreatedBy, user.LastLogin)
+	result, err := db.DB.Exec(query, user.Username, user.Email, user.HashedPassword, user.CreatedBy, user.LastLogin, user.EmailVerifiedAt)
 	if err != nil {
 		log.Error("Failed to insert user: ", err)
 		return 0, err
@@ -600,13 +698,16 @@ func (db *MySQLDB) InsertUser(user model.User) (int64, error) {
 	return userID, nil
 }
 
-// GetUserByID fetches a user by ID
-func (db *MySQLDB) GetUserByID(id int64) (*model.User, error) {
-	query := `SELECT id, username, email, hashed_password, created_at, created_by, last_login FROM users WHERE id = ?`
+// GetUserByID fetches a user by ID; soft-deleted users only with includeDeleted
+func (db *MySQLDB) GetUserByID(id int64, includeDeleted bool) (*model.User, error) {
+	query := `SELECT id, username, email, hashed_password, created_at, created_by, last_login, email_verified_at, deleted_at, deleted_by FROM users WHERE id = ?`
+	if !includeDeleted {
+		query += ` AND deleted_at IS NULL`
+	}
 	row := db.DB.QueryRow(query, id)
 
 	var user model.User
-	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.HashedPassword, &user.CreatedAt, &user.CreatedBy, &user.LastLogin)
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.HashedPassword, &user.CreatedAt, &user.CreatedBy, &user.LastLogin, &user.EmailVerifiedAt, &user.DeletedAt, &user.DeletedBy)
 	if err != nil {
 		log.Error("Failed to get user: ", err)
 		return nil, err
@@ -876,11 +977,11 @@ func (db *MySQLDB) InsertUserRole(ur model.UserRole) error {
 }
 
 func (db *MySQLDB) GetUserByEmail(email string) (*model.User, error) {
-	query := `SELECT id, username, email, hashed_password, created_at, created_by, last_login FROM users WHERE email = ?`
+	query := `SELECT id, username, email, hashed_password, created_at, created_by, last_login, email_verified_at FROM users WHERE email = ? AND deleted_at IS NULL`
 
 	row := db.DB.QueryRow(query, email)
 	var user model.User
-	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.HashedPassword, &user.CreatedAt, &user.CreatedBy, &user.LastLogin)
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.HashedPassword, &user.CreatedAt, &user.CreatedBy, &user.LastLogin, &user.EmailVerifiedAt)
 	if err != nil {
 		log.Error("Failed to fetch user by email: ", err)
 		return nil, err
@@ -889,11 +990,11 @@ func (db *MySQLDB) GetUserByEmail(email string) (*model.User, error) {
 }
 
 func (db *MySQLDB) GetUserByUsername(username string) (*model.User, error) {
-	query := `SELECT id, username, email, hashed_password, created_at, created_by, last_login FROM users WHERE username = ?`
+	query := `SELECT id, username, email, hashed_password, created_at, created_by, last_login, email_verified_at FROM users WHERE username = ? AND deleted_at IS NULL`
 
 	row := db.DB.QueryRow(query, username)
 	var user model.User
-	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.HashedPassword, &user.CreatedAt, &user.CreatedBy, &user.LastLogin)
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.HashedPassword, &user.CreatedAt, &user.CreatedBy, &user.LastLogin, &user.EmailVerifiedAt)
 	if err != nil {
 		log.Error("Failed to fetch user by username: ", err)
 		return nil, err
@@ -907,7 +1008,8 @@ func (db *MySQLDB) GetUserPermissions(userID int64) ([]model.Permission, error)
 		FROM permissions p
 		JOIN role_permissions rp ON rp.permission_id = p.id
 		JOIN user_roles ur ON ur.role_id = rp.role_id
-		WHERE ur.user_id = ?`
+		JOIN users u ON u.id = ur.user_id
+		WHERE ur.user_id = ? AND u.deleted_at IS NULL`
 
 	rows, err := db.DB.Query(query, userID)
 	if err != nil {
@@ -1329,7 +1431,7 @@ func (db *MySQLDB) RunCRUDTests() error {
 	log.Infof("Assigned Permission ID %d to Role ID %d", permID, roleID)
 
 	// Read user and their roles
-	user, err := db.GetUserByID(userID)
+	user, err := db.GetUserByID(userID, false)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"address_module/internal/model"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrDeviceVersionNotFound is returned when reverting to a version that does not exist
+var ErrDeviceVersionNotFound = errors.New("device version not found")
+
+// Every update stores the full device as a new row in device_history. Version
+// 1 is written lazily on the first update and holds the state before it, so
+// devices created before the history existed get a baseline too.
+
+// SetupDeviceHistoryTable creates the table holding device versions
+func (p *PostgresDB) SetupDeviceHistoryTable() error {
+	_, err := p.DB.Exec(`
+	CREATE TABLE IF NOT EXISTS device_history (
+		id BIGSERIAL PRIMARY KEY,
+		device_id BIGINT NOT NULL REFERENCES devices(id) ON DELETE CASCADE,
+		version INT NOT NULL,
+		snapshot JSONB NOT NULL,
+		changed_by BIGINT NULL,
+		changed_at TIMESTAMP NOT NULL DEFAULT now(),
+		reverted_from INT NULL,
+		UNIQUE (device_id, version)
+	);`)
+	if err != nil {
+		log.Error("Failed to create device_history table: ", err)
+		return err
+	}
+	return nil
+}
+
+// deviceHistoryIgnored are the fields that are not part of a device version
+var deviceHistoryIgnored = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"deleted_at": true,
+	"deleted_by": true,
+}
+
+// deviceChanges lists the fields that differ between two device states, in
+// struct order. Values are compared in their JSON form.
+func deviceChanges(before, after *DeviceParams) []model.DeviceFieldChange {
+	changes := []model.DeviceFieldChange{}
+	b, a := reflect.ValueOf(before).Elem(), reflect.ValueOf(after).Elem()
+	for i := 0; i < b.NumField(); i++ {
+		field := strings.Split(b.Type().Field(i).Tag.Get("json"), ",")[0]
+		if field == "" || deviceHistoryIgnored[field] {
+			continue
+		}
+		oldJSON, _ := json.Marshal(b.Field(i).Interface())
+		newJSON, _ := json.Marshal(a.Field(i).Interface())
+		if !bytes.Equal(oldJSON, newJSON) {
+			changes = append(changes, model.DeviceFieldChange{
+				Field:  field,
+				Before: b.Field(i).Interface(),
+				After:  a.Field(i).Interface(),
+			})
+		}
+	}
+	return changes
+}
+
+// ensureBaselineVersion writes version 1 from the current state if the device
+// has no history yet, and returns the latest version number
+func ensureBaselineVersion(tx *sql.Tx, current *DeviceParams) (int, error) {
+	var version int
+	err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM device_history WHERE device_id = $1`, current.ID).Scan(&version)
+	if err != nil {
+		log.Error("Failed to read device history: ", err)
+		return 0, err
+	}
+	if version > 0 {
+		return version, nil
+	}
+	return 1, insertDeviceVersion(tx, current, 1, nil, nil)
+}
+
+func insertDeviceVersion(tx *sql.Tx, d *DeviceParams, version int, changedBy *int64, revertedFrom *int) error {
+	snapshot, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	// The baseline is dated to the device's creation, later versions to now
+	_, err = tx.Exec(`
+	INSERT INTO device_history (device_id, version, snapshot, changed_by, changed_at, reverted_from)
+	SELECT $1::bigint, $2::int, $3::jsonb, $4::bigint,
+	       CASE WHEN $2::int = 1 THEN COALESCE(created_at, now()) ELSE now() END, $5::int
+	FROM devices WHERE id = $1::bigint`,
+		d.ID, version, string(snapshot), changedBy, revertedFrom)
+	if err != nil {
+		log.Error("Failed to insert device version: ", err)
+	}
+	return err
+}
+
+// GetDeviceHistory returns a device's versions, oldest first, each with the
+// fields that changed compared to the version before it
+func (p *PostgresDB) GetDeviceHistory(deviceID int64) ([]model.DeviceVersion, error) {
+	rows, err := p.DB.Query(`
+	SELECT version, snapshot, changed_by, changed_at, reverted_from
+	FROM device_history
+	WHERE device_id = $1
+	ORDER BY version`, deviceID)
+	if err != nil {
+		log.Error("Failed to query device history: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []model.DeviceVersion{}
+	var previous *DeviceParams
+	for rows.Next() {
+		var v model.DeviceVersion
+		var snapshot []byte
+		if err := rows.Scan(&v.Version, &snapshot, &v.ChangedBy, &v.ChangedAt, &v.RevertedFrom); err != nil {
+			return nil, err
+		}
+		var state DeviceParams
+		if err := json.Unmarshal(snapshot, &state); err != nil {
+			return nil, err
+		}
+
+		v.Changes = []model.DeviceFieldChange{}
+		if previous != nil {
+			v.Changes = deviceChanges(previous, &state)
+		}
+		previous = &state
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// RevertDevice restores the fields of an earlier version. The revert is itself
+// recorded as a new version. Returns ErrDeviceVersionNotFound for an unknown
+// version and sql.ErrNoRows if the device does not exist or is soft-deleted.
+func (p *PostgresDB) RevertDevice(deviceID int64, version int, changedBy *int64) (*DeviceParams, error) {
+	var snapshot []byte
+	err := p.DB.QueryRow(`SELECT snapshot FROM device_history WHERE device_id = $1 AND version = $2`, deviceID, version).Scan(&snapshot)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeviceVersionNotFound
+	}
+	if err != nil {
+		log.Error("Failed to load device version: ", err)
+		return nil, err
+	}
+
+	var state DeviceParams
+	if err := json.Unmarshal(snapshot, &state); err != nil {
+		return nil, err
+	}
+	state.ID = deviceID
+
+	if err := p.updateDevice(&state, changedBy, &version); err != nil {
+		return nil, err
+	}
+	return p.GetDeviceByID(deviceID, false)
+}
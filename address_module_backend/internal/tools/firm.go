@@ -0,0 +1,97 @@
+package tools
+
+import (
+	log "github.com/sirupsen/logrus"
+)
+
+// GetFirmByID fetches a firm; soft-deleted firms only with includeDeleted
+func (db *MySQLDB) GetFirmByID(id int64, includeDeleted bool) (*FirmParams, error) {
+	query := `
+	SELECT id, anrede, name_1, COALESCE(name_2, ''), COALESCE(name_3, ''), COALESCE(straße, ''),
+	       COALESCE(land, ''), plz, ort, telefon, email, COALESCE(website, ''), kunde,
+	       lieferant, gesperrt, COALESCE(bemerkung, ''), COALESCE(firma_typ, ''), deleted_at, deleted_by
+	FROM firms
+	WHERE id = ?`
+	if !includeDeleted {
+		query += ` AND deleted_at IS NULL`
+	}
+
+	var firm FirmParams
+	err := db.DB.QueryRow(query, id).Scan(
+		&firm.ID, &firm.Anrede, &firm.Name1, &firm.Name2, &firm.Name3,
+		&firm.Straße, &firm.Land, &firm.PLZ, &firm.Ort, &firm.Telefon,
+		&firm.Email, &firm.Website, &firm.Kunde, &firm.Lieferant,
+		&firm.Gesperrt, &firm.Bemerkung, &firm.FirmaTyp, &firm.DeletedAt, &firm.DeletedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &firm, nil
+}
+
+// GetContactsByFirmID retrieves all contacts associated with a specific firm
+func (db *MySQLDB) GetContactsByFirmID(firmID int64) ([]ContactParams, error) {
+	query := `
+	SELECT c.id, COALESCE(c.anrede, ''), c.vorname, c.nachname, COALESCE(c.position, ''),
+	       COALESCE(c.telefon, ''), COALESCE(c.mobil, ''), c.email, COALESCE(c.abteilung, ''),
+	       COALESCE(CAST(c.geburtstag AS CHAR), ''), COALESCE(c.bemerkung, ''), c.kontotyp,
+	       COALESCE(fc.beziehung, ''), COALESCE(fc.hauptansprechpartner, FALSE)
+	FROM contacts c
+	JOIN firms_contacts fc ON c.id = fc.contact_id
+	WHERE fc.firma_id = ? AND c.deleted_at IS NULL
+	ORDER BY fc.hauptansprechpartner DESC, c.nachname, c.vorname`
+
+	rows, err := db.DB.Query(query, firmID)
+	if err != nil {
+		log.Error("Failed to query contacts by firm ID: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contacts []ContactParams
+	for rows.Next() {
+		var contact ContactParams
+		err := rows.Scan(
+			&contact.ID, &contact.Anrede, &contact.Vorname, &contact.Nachname, &contact.Position,
+			&contact.Telefon, &contact.Mobil, &contact.Email, &contact.Abteilung,
+			&contact.Geburtstag, &contact.Bemerkung, &contact.Kontotyp,
+			&contact.Relation.Beziehung, &contact.Relation.Hauptansprechpartner,
+		)
+		if err != nil {
+			log.Error("Failed to scan contact row: ", err)
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Error("Error during rows iteration: ", err)
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+// UpdateFirm replaces the fields of a firm; sql.ErrNoRows if it does not exist
+func (db *MySQLDB) UpdateFirm(firm FirmParams) error {
+	if _, err := db.GetFirmByID(firm.ID, false); err != nil {
+		return err
+	}
+
+	query := `
+	UPDATE firms
+	SET anrede = ?, name_1 = ?, name_2 = ?, name_3 = ?, straße = ?, land = ?, plz = ?, ort = ?,
+	    telefon = ?, email = ?, website = ?, kunde = ?, lieferant = ?, gesperrt = ?, bemerkung = ?, firma_typ = ?
+	WHERE id = ?`
+
+	_, err := db.DB.Exec(query, firm.Anrede, firm.Name1, firm.Name2, firm.Name3, firm.Straße, firm.Land,
+		firm.PLZ, firm.Ort, firm.Telefon, firm.Email, firm.Website, firm.Kunde, firm.Lieferant,
+		firm.Gesperrt, firm.Bemerkung, firm.FirmaTyp, firm.ID)
+	if err != nil {
+		log.Error("Failed to update firm: ", err)
+		return err
+	}
+
+	log.Info("Firm updated successfully")
+	return nil
+}
@@ -0,0 +1,31 @@
+package tools
+
+import (
+	"address_module/internal/model"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// GetInboundMail returns what an already ingested message turned into, or sql.ErrNoRows
+func (db *MySQLDB) GetInboundMail(messageID string) (*model.InboundMailResult, error) {
+	result := model.InboundMailResult{Action: "duplicate"}
+	err := db.DB.QueryRow(`
+	SELECT ticket_id, comment_id FROM inbound_mail WHERE message_id = ?`, messageID).Scan(
+		&result.TicketID, &result.CommentID)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RecordInboundMail remembers an ingested message so a redelivery is not processed twice
+func (db *MySQLDB) RecordInboundMail(messageID, sender string, ticketID, commentID *int64) error {
+	_, err := db.DB.Exec(`
+	INSERT IGNORE INTO inbound_mail (message_id, sender, ticket_id, comment_id)
+	VALUES (?, ?, ?, ?)`, messageID, sender, ticketID, commentID)
+	if err != nil {
+		log.Error("Failed to record inbound mail: ", err)
+		return err
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package tools
 
 import (
+	"address_module/internal/model"
+	"database/sql"
+
 	_ "github.com/go-sql-driver/mysql"
 	log "github.com/sirupsen/logrus"
 )
@@ -91,6 +94,74 @@ func (db *MySQLDB) SetupFirmsContactsRelationTable() error {
 	return nil
 }
 
+// MigrateContactsTable adds columns introduced after the contacts table was created
+func (db *MySQLDB) MigrateContactsTable() error {
+	migrations := []struct {
+		column string
+		alter  string
+	}{
+		{"deleted_at", "ADD COLUMN deleted_at DATETIME NULL"},
+		{"deleted_by", "ADD COLUMN deleted_by INT NULL, ADD CONSTRAINT fk_contacts_deleted_by FOREIGN KEY (deleted_by) REFERENCES users(id) ON DELETE SET NULL"},
+	}
+
+	for _, m := range migrations {
+		if err := db.ensureColumn("contacts", m.column, m.alter); err != nil {
+			log.Errorf("Failed to migrate contacts.%s: %v", m.column, err)
+			return err
+		}
+	}
+	log.Info("Contacts table migration completed")
+	return nil
+}
+
+// MigrateUsersTable adds columns introduced after the users table was created
+func (db *MySQLDB) MigrateUsersTable() error {
+	migrations := []struct {
+		column string
+		alter  string
+	}{
+		{"deleted_at", "ADD COLUMN deleted_at DATETIME NULL"},
+		{"deleted_by", "ADD COLUMN deleted_by INT NULL, ADD CONSTRAINT fk_users_deleted_by FOREIGN KEY (deleted_by) REFERENCES users(id) ON DELETE SET NULL"},
+		{"email_verified_at", "ADD COLUMN email_verified_at DATETIME NULL"},
+	}
+
+	for _, m := range migrations {
+		if err := db.ensureColumn("users", m.column, m.alter); err != nil {
+			log.Errorf("Failed to migrate users.%s: %v", m.column, err)
+			return err
+		}
+	}
+	log.Info("Users table migration completed")
+	return nil
+}
+
+// MigrateFirmsContactsTable lets the database enforce at most one primary
+// contact per firm. Firms that already have several keep the oldest one.
+func (db *MySQLDB) MigrateFirmsContactsTable() error {
+	_, err := db.DB.Exec(`
+	UPDATE firms_contacts fc
+	JOIN (
+		SELECT firma_id, MIN(id) AS keep_id FROM firms_contacts
+		WHERE hauptansprechpartner
+		GROUP BY firma_id
+	) p ON p.firma_id = fc.firma_id
+	SET fc.hauptansprechpartner = FALSE
+	WHERE fc.hauptansprechpartner AND fc.id <> p.keep_id`)
+	if err != nil {
+		log.Error("Failed to clean up duplicate primary contacts: ", err)
+		return err
+	}
+
+	err = db.ensureColumn("firms_contacts", "primary_firma_id",
+		"ADD COLUMN primary_firma_id INT AS (IF(hauptansprechpartner, firma_id, NULL)) STORED, ADD UNIQUE KEY unique_primary_contact (primary_firma_id)")
+	if err != nil {
+		log.Error("Failed to migrate firms_contacts.primary_firma_id: ", err)
+		return err
+	}
+	log.Info("Firms-Contacts table migration completed")
+	return nil
+}
+
 // SetupPerformanceIndexes creates additional indexes
 func (db *MySQLDB) SetupPerformanceIndexes() error {
 	queries := []string{
@@ -210,6 +281,635 @@ func (db *MySQLDB) SetupUserRolesTable() error {
 	return nil
 }
 
+// SetupTicketsTable creates the tickets table
+func (db *MySQLDB) SetupTicketsTable() error {
+	query := `
+    CREATE TABLE IF NOT EXISTS tickets (
+        id INT AUTO_INCREMENT PRIMARY KEY,
+        title VARCHAR(255) NOT NULL,
+        description TEXT,
+        status VARCHAR(50) NOT NULL DEFAULT 'new',
+        priority VARCHAR(20) NOT NULL DEFAULT 'normal',
+        contact_id INT NULL,
+        assignee_id INT NULL,
+        firma_id INT NULL,
+        created_by INT NULL,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+        FOREIGN KEY (contact_id) REFERENCES contacts(id) ON DELETE SET NULL,
+        FOREIGN KEY (assignee_id) REFERENCES users(id) ON DELETE SET NULL,
+        FOREIGN KEY (firma_id) REFERENCES firms(id) ON DELETE SET NULL,
+        FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL,
+        INDEX idx_tickets_status (status),
+        INDEX idx_tickets_assignee (assignee_id)
+    );`
+
+	_, err := db.DB.Exec(query)
+	if err != nil {
+		log.Error("Failed to create tickets table: ", err)
+		return err
+	}
+	log.Info("Tickets table setup completed")
+	return nil
+}
+
+// SetupWorkflowTables creates the workflow definition and ticket status history tables
+func (db *MySQLDB) SetupWorkflowTables() error {
+	queries := []string{`
+    CREATE TABLE IF NOT EXISTS workflows (
+        id INT AUTO_INCREMENT PRIMARY KEY,
+        name VARCHAR(100) NOT NULL UNIQUE,
+        description VARCHAR(255),
+        initial_status VARCHAR(50) NOT NULL,
+        is_default BOOLEAN DEFAULT FALSE,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );`, `
+    CREATE TABLE IF NOT EXISTS workflow_transitions (
+        id INT AUTO_INCREMENT PRIMARY KEY,
+        workflow_id INT NOT NULL,
+        from_status VARCHAR(50) NOT NULL,
+        to_status VARCHAR(50) NOT NULL,
+        name VARCHAR(100),
+        FOREIGN KEY (workflow_id) REFERENCES workflows(id) ON DELETE CASCADE,
+        UNIQUE KEY unique_workflow_transition (workflow_id, from_status, to_status)
+    );`, `
+    CREATE TABLE IF NOT EXISTS workflow_closed_statuses (
+        workflow_id INT NOT NULL,
+        status VARCHAR(50) NOT NULL,
+        PRIMARY KEY (workflow_id, status),
+        FOREIGN KEY (workflow_id) REFERENCES workflows(id) ON DELETE CASCADE
+    );`, `
+    CREATE TABLE IF NOT EXISTS ticket_status_history (
+        id INT AUTO_INCREMENT PRIMARY KEY,
+        ticket_id INT NOT NULL,
+        from_status VARCHAR(50) NOT NULL,
+        to_status VARCHAR(50) NOT NULL,
+        changed_by INT NULL,
+        comment TEXT,
+        changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE CASCADE,
+        FOREIGN KEY (changed_by) REFERENCES users(id) ON DELETE SET NULL
+    );`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.DB.Exec(query); err != nil {
+			log.Error("Failed to create workflow tables: ", err)
+			return err
+		}
+	}
+	log.Info("Workflow tables setup completed")
+	return nil
+}
+
+// SetupTicketDevicesTable creates the link table between tickets and devices.
+// Devices live in the Postgres device database, so device_id carries no foreign key.
+func (db *MySQLDB) SetupTicketDevicesTable() error {
+	query := `
+    CREATE TABLE IF NOT EXISTS ticket_devices (
+        ticket_id INT NOT NULL,
+        device_id INT NOT NULL,
+        name VARCHAR(255),
+        hostname VARCHAR(255),
+        ip VARCHAR(50),
+        location_text TEXT,
+        device_deleted BOOLEAN DEFAULT FALSE,
+        linked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (ticket_id, device_id),
+        FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE CASCADE,
+        INDEX idx_ticket_devices_device (device_id)
+    );`
+
+	_, err := db.DB.Exec(query)
+	if err != nil {
+		log.Error("Failed to create ticket_devices table: ", err)
+		return err
+	}
+	log.Info("Ticket-Devices table setup completed")
+	return nil
+}
+
+// SetupTicketCommentsTable creates the table holding ticket replies and internal notes
+func (db *MySQLDB) SetupTicketCommentsTable() error {
+	query := `
+    CREATE TABLE IF NOT EXISTS ticket_comments (
+        id INT AUTO_INCREMENT PRIMARY KEY,
+        ticket_id INT NOT NULL,
+        author_id INT NULL,
+        body TEXT NOT NULL,
+        internal BOOLEAN DEFAULT FALSE,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE CASCADE,
+        FOREIGN KEY (author_id) REFERENCES users(id) ON DELETE SET NULL,
+        INDEX idx_ticket_comments_ticket (ticket_id, created_at)
+    );`
+
+	_, err := db.DB.Exec(query)
+	if err != nil {
+		log.Error("Failed to create ticket_comments table: ", err)
+		return err
+	}
+	log.Info("Ticket comments table setup completed")
+	return nil
+}
+
+// ensureColumn runs `ALTER TABLE <table> <alter>` if the column does not exist yet.
+// CREATE TABLE IF NOT EXISTS never touches existing tables, so columns added
+// after a table was first released are migrated in through this helper.
+func (db *MySQLDB) ensureColumn(table, column, alter string) error {
+	var count int
+	err := db.DB.QueryRow(`
+	SELECT COUNT(*) FROM information_schema.columns
+	WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?`, table, column).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	if _, err := db.DB.Exec("ALTER TABLE " + table + " " + alter); err != nil {
+		return err
+	}
+	log.Infof("Added column %s.%s", table, column)
+	return nil
+}
+
+// ensureIndex runs `ALTER TABLE <table> <alter>` if the index does not exist yet
+func (db *MySQLDB) ensureIndex(table, index, alter string) error {
+	var count int
+	err := db.DB.QueryRow(`
+	SELECT COUNT(*) FROM information_schema.statistics
+	WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?`, table, index).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	if _, err := db.DB.Exec("ALTER TABLE " + table + " " + alter); err != nil {
+		return err
+	}
+	log.Infof("Added index %s.%s", table, index)
+	return nil
+}
+
+// SetupSearchIndexes creates the FULLTEXT indexes used by /search
+func (db *MySQLDB) SetupSearchIndexes() error {
+	indexes := []struct {
+		table string
+		index string
+		alter string
+	}{
+		{"firms", "ft_firms_search", "ADD FULLTEXT INDEX ft_firms_search (name_1, name_2, name_3, ort, plz)"},
+		{"contacts", "ft_contacts_search", "ADD FULLTEXT INDEX ft_contacts_search (vorname, nachname, email)"},
+		{"tickets", "ft_tickets_search", "ADD FULLTEXT INDEX ft_tickets_search (title, description)"},
+	}
+
+	for _, i := range indexes {
+		if err := db.ensureIndex(i.table, i.index, i.alter); err != nil {
+			log.Errorf("Failed to create search index %s: %v", i.index, err)
+			return err
+		}
+	}
+	log.Info("Search indexes setup completed")
+	return nil
+}
+
+// MigrateTicketsTable adds columns introduced after the tickets table was created
+func (db *MySQLDB) MigrateTicketsTable() error {
+	migrations := []struct {
+		column string
+		alter  string
+	}{
+		{"workflow_id", "ADD COLUMN workflow_id INT NULL AFTER firma_id, ADD CONSTRAINT fk_tickets_workflow FOREIGN KEY (workflow_id) REFERENCES workflows(id) ON DELETE SET NULL"},
+		{"first_response_due_at", "ADD COLUMN first_response_due_at DATETIME NULL"},
+		{"resolution_due_at", "ADD COLUMN resolution_due_at DATETIME NULL"},
+		{"first_responded_at", "ADD COLUMN first_responded_at DATETIME NULL"},
+		{"escalated_at", "ADD COLUMN escalated_at DATETIME NULL"},
+		{"queue_id", "ADD COLUMN queue_id INT NULL, ADD CONSTRAINT fk_tickets_queue FOREIGN KEY (queue_id) REFERENCES queues(id) ON DELETE SET NULL"},
+	}
+
+	for _, m := range migrations {
+		if err := db.ensureColumn("tickets", m.column, m.alter); err != nil {
+			log.Errorf("Failed to migrate tickets.%s: %v", m.column, err)
+			return err
+		}
+	}
+	log.Info("Tickets table migration completed")
+	return nil
+}
+
+// MigrateTicketCommentsTable adds columns introduced after the ticket_comments table was created
+func (db *MySQLDB) MigrateTicketCommentsTable() error {
+	migrations := []struct {
+		column string
+		alter  string
+	}{
+		{"author_contact_id", "ADD COLUMN author_contact_id INT NULL AFTER author_id, ADD CONSTRAINT fk_ticket_comments_contact FOREIGN KEY (author_contact_id) REFERENCES contacts(id) ON DELETE SET NULL"},
+	}
+
+	for _, m := range migrations {
+		if err := db.ensureColumn("ticket_comments", m.column, m.alter); err != nil {
+			log.Errorf("Failed to migrate ticket_comments.%s: %v", m.column, err)
+			return err
+		}
+	}
+	log.Info("Ticket comments table migration completed")
+	return nil
+}
+
+// SetupInboundMailTable creates the log of ingested emails, keyed by Message-ID
+// so a mail delivered twice does not create a second ticket or comment
+func (db *MySQLDB) SetupInboundMailTable() error {
+	query := `
+    CREATE TABLE IF NOT EXISTS inbound_mail (
+        message_id VARCHAR(255) PRIMARY KEY,
+        sender VARCHAR(255) NOT NULL,
+        ticket_id INT NULL,
+        comment_id INT NULL,
+        received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE SET NULL,
+        FOREIGN KEY (comment_id) REFERENCES ticket_comments(id) ON DELETE SET NULL
+    );`
+
+	_, err := db.DB.Exec(query)
+	if err != nil {
+		log.Error("Failed to create inbound_mail table: ", err)
+		return err
+	}
+	log.Info("Inbound mail table setup completed")
+	return nil
+}
+
+// SetupTicketAttachmentsTable creates the table of files attached to tickets and comments
+func (db *MySQLDB) SetupTicketAttachmentsTable() error {
+	query := `
+    CREATE TABLE IF NOT EXISTS ticket_attachments (
+        id INT AUTO_INCREMENT PRIMARY KEY,
+        ticket_id INT NOT NULL,
+        comment_id INT NULL,
+        filename VARCHAR(255) NOT NULL,
+        content_type VARCHAR(100) NOT NULL,
+        size BIGINT NOT NULL,
+        sha256 CHAR(64) NOT NULL,
+        uploaded_by INT NULL,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE CASCADE,
+        FOREIGN KEY (comment_id) REFERENCES ticket_comments(id) ON DELETE CASCADE,
+        FOREIGN KEY (uploaded_by) REFERENCES users(id) ON DELETE SET NULL,
+        INDEX idx_ticket_attachments_ticket (ticket_id),
+        INDEX idx_ticket_attachments_sha (sha256)
+    );`
+
+	_, err := db.DB.Exec(query)
+	if err != nil {
+		log.Error("Failed to create ticket_attachments table: ", err)
+		return err
+	}
+	log.Info("Ticket attachments table setup completed")
+	return nil
+}
+
+// SetupQueueTables creates the support queues and their memberships
+func (db *MySQLDB) SetupQueueTables() error {
+	queries := []string{`
+    CREATE TABLE IF NOT EXISTS queues (
+        id INT AUTO_INCREMENT PRIMARY KEY,
+        name VARCHAR(100) NOT NULL UNIQUE,
+        description VARCHAR(255),
+        assignment_mode VARCHAR(20) NOT NULL DEFAULT 'manual',
+        required_role_id INT NULL,
+        last_assigned_user_id INT NULL,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        FOREIGN KEY (required_role_id) REFERENCES roles(id) ON DELETE SET NULL,
+        FOREIGN KEY (last_assigned_user_id) REFERENCES users(id) ON DELETE SET NULL
+    );`, `
+    CREATE TABLE IF NOT EXISTS queue_members (
+        queue_id INT NOT NULL,
+        user_id INT NOT NULL,
+        PRIMARY KEY (queue_id, user_id),
+        FOREIGN KEY (queue_id) REFERENCES queues(id) ON DELETE CASCADE,
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.DB.Exec(query); err != nil {
+			log.Error("Failed to create queue tables: ", err)
+			return err
+		}
+	}
+	log.Info("Queue tables setup completed")
+	return nil
+}
+
+// SetupSLATables creates the SLA policy, target and holiday tables
+func (db *MySQLDB) SetupSLATables() error {
+	queries := []string{`
+    CREATE TABLE IF NOT EXISTS sla_policies (
+        id INT AUTO_INCREMENT PRIMARY KEY,
+        name VARCHAR(100) NOT NULL UNIQUE,
+        description VARCHAR(255),
+        timezone VARCHAR(64) NOT NULL DEFAULT 'Europe/Berlin',
+        business_hours_start CHAR(5) NOT NULL DEFAULT '08:00',
+        business_hours_end CHAR(5) NOT NULL DEFAULT '17:00',
+        work_days VARCHAR(20) NOT NULL DEFAULT '1,2,3,4,5',
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );`, `
+    CREATE TABLE IF NOT EXISTS sla_targets (
+        policy_id INT NOT NULL,
+        priority VARCHAR(20) NOT NULL,
+        first_response_minutes INT NOT NULL,
+        resolution_minutes INT NOT NULL,
+        PRIMARY KEY (policy_id, priority),
+        FOREIGN KEY (policy_id) REFERENCES sla_policies(id) ON DELETE CASCADE
+    );`, `
+    CREATE TABLE IF NOT EXISTS sla_holidays (
+        id INT AUTO_INCREMENT PRIMARY KEY,
+        policy_id INT NOT NULL,
+        holiday DATE NOT NULL,
+        description VARCHAR(255),
+        FOREIGN KEY (policy_id) REFERENCES sla_policies(id) ON DELETE CASCADE,
+        UNIQUE KEY unique_policy_holiday (policy_id, holiday)
+    );`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.DB.Exec(query); err != nil {
+			log.Error("Failed to create SLA tables: ", err)
+			return err
+		}
+	}
+	log.Info("SLA tables setup completed")
+	return nil
+}
+
+// MigrateFirmsTable adds columns introduced after the firms table was created
+func (db *MySQLDB) MigrateFirmsTable() error {
+	migrations := []struct {
+		column string
+		alter  string
+	}{
+		{"sla_policy_id", "ADD COLUMN sla_policy_id INT NULL, ADD CONSTRAINT fk_firms_sla_policy FOREIGN KEY (sla_policy_id) REFERENCES sla_policies(id) ON DELETE SET NULL"},
+		{"deleted_at", "ADD COLUMN deleted_at DATETIME NULL"},
+		{"deleted_by", "ADD COLUMN deleted_by INT NULL, ADD CONSTRAINT fk_firms_deleted_by FOREIGN KEY (deleted_by) REFERENCES users(id) ON DELETE SET NULL"},
+	}
+
+	for _, m := range migrations {
+		if err := db.ensureColumn("firms", m.column, m.alter); err != nil {
+			log.Errorf("Failed to migrate firms.%s: %v", m.column, err)
+			return err
+		}
+	}
+	log.Info("Firms table migration completed")
+	return nil
+}
+
+// SetupSchedulerTables creates the tables the background jobs use for bookkeeping
+func (db *MySQLDB) SetupSchedulerTables() error {
+	queries := []string{`
+    CREATE TABLE IF NOT EXISTS scheduler_runs (
+        job_name VARCHAR(100) PRIMARY KEY,
+        last_run_at DATETIME NOT NULL,
+        last_duration_ms INT NOT NULL DEFAULT 0,
+        last_status VARCHAR(20) NOT NULL,
+        last_error TEXT
+    );`, `
+    CREATE TABLE IF NOT EXISTS warranty_notices (
+        device_id BIGINT NOT NULL,
+        warranty_until DATE NOT NULL,
+        ticket_id INT NULL,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (device_id, warranty_until),
+        FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE SET NULL
+    );`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.DB.Exec(query); err != nil {
+			log.Error("Failed to create scheduler tables: ", err)
+			return err
+		}
+	}
+	log.Info("Scheduler tables setup completed")
+	return nil
+}
+
+// SetupEmailOutboxTable creates the queue of outgoing notification emails
+func (db *MySQLDB) SetupEmailOutboxTable() error {
+	query := `
+    CREATE TABLE IF NOT EXISTS email_outbox (
+        id INT AUTO_INCREMENT PRIMARY KEY,
+        ticket_id INT NULL,
+        event VARCHAR(50) NOT NULL,
+        recipient VARCHAR(255) NOT NULL,
+        subject VARCHAR(255) NOT NULL,
+        body TEXT NOT NULL,
+        status VARCHAR(20) NOT NULL DEFAULT 'pending',
+        attempts INT NOT NULL DEFAULT 0,
+        last_error TEXT,
+        next_attempt_at DATETIME NOT NULL,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        sent_at DATETIME NULL,
+        FOREIGN KEY (ticket_id) REFERENCES tickets(id) ON DELETE SET NULL,
+        INDEX idx_email_outbox_due (status, next_attempt_at)
+    );`
+
+	_, err := db.DB.Exec(query)
+	if err != nil {
+		log.Error("Failed to create email_outbox table: ", err)
+		return err
+	}
+	log.Info("Email outbox table setup completed")
+	return nil
+}
+
+// SetupAuditLogTable creates the audit log. user_id has no foreign key so
+// entries outlive purged users. Triggers make the table append-only.
+func (db *MySQLDB) SetupAuditLogTable() error {
+	query := `
+    CREATE TABLE IF NOT EXISTS audit_log (
+        id BIGINT AUTO_INCREMENT PRIMARY KEY,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        user_id BIGINT NULL,
+        action VARCHAR(20) NOT NULL,
+        entity_type VARCHAR(50) NOT NULL,
+        entity_id BIGINT NOT NULL,
+        changes JSON NOT NULL,
+        INDEX idx_audit_entity (entity_type, entity_id),
+        INDEX idx_audit_user (user_id),
+        INDEX idx_audit_created (created_at)
+    );`
+
+	if _, err := db.DB.Exec(query); err != nil {
+		log.Error("Failed to create audit_log table: ", err)
+		return err
+	}
+
+	triggers := map[string]string{
+		"audit_log_no_update": "BEFORE UPDATE",
+		"audit_log_no_delete": "BEFORE DELETE",
+	}
+	for name, timing := range triggers {
+		var exists int
+		err := db.DB.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.TRIGGERS
+		WHERE TRIGGER_SCHEMA = DATABASE() AND TRIGGER_NAME = ?`, name).Scan(&exists)
+		if err != nil {
+			log.Error("Failed to check audit_log triggers: ", err)
+			return err
+		}
+		if exists > 0 {
+			continue
+		}
+		_, err = db.DB.Exec(`CREATE TRIGGER ` + name + ` ` + timing + ` ON audit_log FOR EACH ROW
+			SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_log is append-only'`)
+		if err != nil {
+			// Needs SUPER or log_bin_trust_function_creators while binary logging is on;
+			// the API never updates or deletes entries either way
+			log.Warnf("Could not create %s trigger, audit_log is append-only by convention only: %v", name, err)
+		}
+	}
+
+	log.Info("Audit log table setup completed")
+	return nil
+}
+
+// SetupSchemaMigrationsTable creates the table recording one-time data migrations
+func (db *MySQLDB) SetupSchemaMigrationsTable() error {
+	query := `
+    CREATE TABLE IF NOT EXISTS schema_migrations (
+        name VARCHAR(100) PRIMARY KEY,
+        applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );`
+
+	if _, err := db.DB.Exec(query); err != nil {
+		log.Error("Failed to create schema_migrations table: ", err)
+		return err
+	}
+	log.Info("Schema migrations table setup completed")
+	return nil
+}
+
+// SetupPermissionVersionTable creates the single-row counter that is bumped
+// whenever role or permission grants change
+func (db *MySQLDB) SetupPermissionVersionTable() error {
+	query := `
+    CREATE TABLE IF NOT EXISTS permission_version (
+        id TINYINT PRIMARY KEY,
+        version BIGINT NOT NULL DEFAULT 1
+    );`
+
+	if _, err := db.DB.Exec(query); err != nil {
+		log.Error("Failed to create permission_version table: ", err)
+		return err
+	}
+	if _, err := db.DB.Exec(`INSERT IGNORE INTO permission_version (id, version) VALUES (1, 1)`); err != nil {
+		log.Error("Failed to initialize permission_version: ", err)
+		return err
+	}
+	log.Info("Permission version table setup completed")
+	return nil
+}
+
+// SetupSessionTables creates the refresh token store and the access token
+// revocation list. Refresh tokens are stored as SHA-256 hashes; every row also
+// remembers the access token issued with it so a session can be revoked whole.
+func (db *MySQLDB) SetupSessionTables() error {
+	queries := []string{`
+    CREATE TABLE IF NOT EXISTS refresh_tokens (
+        id BIGINT AUTO_INCREMENT PRIMARY KEY,
+        user_id INT NOT NULL,
+        family_id CHAR(32) NOT NULL,
+        token_hash CHAR(64) NOT NULL UNIQUE,
+        access_jti CHAR(32) NOT NULL,
+        access_expires_at TIMESTAMP NOT NULL,
+        expires_at TIMESTAMP NOT NULL,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        used_at TIMESTAMP NULL,
+        revoked_at TIMESTAMP NULL,
+        replaced_by BIGINT NULL,
+        INDEX idx_refresh_user (user_id),
+        INDEX idx_refresh_family (family_id),
+        INDEX idx_refresh_expires (expires_at),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );`, `
+    CREATE TABLE IF NOT EXISTS revoked_tokens (
+        jti CHAR(32) PRIMARY KEY,
+        user_id INT NOT NULL,
+        expires_at TIMESTAMP NOT NULL,
+        revoked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        INDEX idx_revoked_expires (expires_at)
+    );`,
+	}
+
+	for _, query := range queries {
+		if _, err := db.DB.Exec(query); err != nil {
+			log.Error("Failed to create session tables: ", err)
+			return err
+		}
+	}
+	log.Info("Session tables setup completed")
+	return nil
+}
+
+// SetupUserTokensTable creates the store for single-use password reset and
+// email verification tokens. Only SHA-256 hashes of the tokens are kept.
+func (db *MySQLDB) SetupUserTokensTable() error {
+	query := `
+    CREATE TABLE IF NOT EXISTS user_tokens (
+        id BIGINT AUTO_INCREMENT PRIMARY KEY,
+        user_id INT NOT NULL,
+        purpose VARCHAR(30) NOT NULL,
+        token_hash CHAR(64) NOT NULL UNIQUE,
+        expires_at TIMESTAMP NOT NULL,
+        used_at TIMESTAMP NULL,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        INDEX idx_user_tokens_user (user_id, purpose),
+        INDEX idx_user_tokens_expires (expires_at),
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    );`
+
+	if _, err := db.DB.Exec(query); err != nil {
+		log.Error("Failed to create user_tokens table: ", err)
+		return err
+	}
+	log.Info("User tokens table setup completed")
+	return nil
+}
+
+// MigrateEmailVerification counts every user that existed before email
+// verification was introduced as verified, so nobody is locked out
+func (db *MySQLDB) MigrateEmailVerification() error {
+	return db.runMigration("verify_existing_users", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL`)
+		return err
+	})
+}
+
+// MigrateOutboxAccountEmails redacts password reset and verification emails
+// delivered or given up on before their bodies were blanked on leaving the queue
+func (db *MySQLDB) MigrateOutboxAccountEmails() error {
+	return db.runMigration("redact_sent_account_emails", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`UPDATE email_outbox SET `+redactedBody+` WHERE status <> ?`, model.EmailStatusPending)
+		return err
+	})
+}
+
+// MigrateWorkflowClosedStatuses marks resolved and closed as closed statuses in
+// the workflows that existed while those two were closed for every workflow
+func (db *MySQLDB) MigrateWorkflowClosedStatuses() error {
+	return db.runMigration("workflow_closed_statuses", func(tx *sql.Tx) error {
+		_, err := tx.Exec(`
+		INSERT IGNORE INTO workflow_closed_statuses (workflow_id, status)
+		SELECT DISTINCT workflow_id, to_status FROM workflow_transitions
+		WHERE to_status IN ('resolved', 'closed')`)
+		return err
+	})
+}
+
 // SetupDatabase sets up all tables and indexes
 func (db *MySQLDB) SetupDatabase() error {
 	// Order matters due to foreign key constraints
@@ -222,6 +922,31 @@ func (db *MySQLDB) SetupDatabase() error {
 		db.SetupPermissionsTable,
 		db.SetupRolePermissionsTable,
 		db.SetupUserRolesTable,
+		db.SetupTicketsTable,
+		db.SetupWorkflowTables,
+		db.SetupQueueTables,
+		db.MigrateTicketsTable,
+		db.SetupTicketDevicesTable,
+		db.SetupTicketCommentsTable,
+		db.SetupSLATables,
+		db.MigrateFirmsTable,
+		db.MigrateFirmsContactsTable,
+		db.MigrateContactsTable,
+		db.MigrateUsersTable,
+		db.SetupSchedulerTables,
+		db.SetupEmailOutboxTable,
+		db.MigrateTicketCommentsTable,
+		db.SetupInboundMailTable,
+		db.SetupTicketAttachmentsTable,
+		db.SetupAuditLogTable,
+		db.SetupSchemaMigrationsTable,
+		db.SetupPermissionVersionTable,
+		db.SetupSessionTables,
+		db.SetupUserTokensTable,
+		db.MigrateEmailVerification,
+		db.MigrateOutboxAccountEmails,
+		db.MigrateWorkflowClosedStatuses,
+		db.SetupSearchIndexes,
 		//db.SetupPerformanceIndexes,
 	}
 
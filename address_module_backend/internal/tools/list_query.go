@@ -0,0 +1,282 @@
+package tools
+
+import (
+	"address_module/internal/model"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// sqlDialect covers the differences between MySQL and Postgres that list queries run into
+type sqlDialect struct {
+	placeholder func(n int) string       // n-th bind parameter, starting at 1
+	text        func(expr string) string // expr rendered as text, used for cursors
+}
+
+var mysqlDialect = sqlDialect{
+	placeholder: func(int) string { return "?" },
+	text:        func(expr string) string { return "CAST(" + expr + " AS CHAR)" },
+}
+
+var postgresDialect = sqlDialect{
+	placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+	text:        func(expr string) string { return "(" + expr + ")::text" },
+}
+
+// listClauses builds the filter WHERE clause (shared with the count query),
+// the keyset condition for cursors and the ORDER BY clause
+func (d sqlDialect) listClauses(q model.ListQuery) (where string, args []interface{}, order string) {
+	var conds []string
+
+	// Filters are applied in a fixed order so the generated SQL is stable
+	filters := append([]model.ListFilter(nil), q.Filters...)
+	sort.Slice(filters, func(i, j int) bool { return filters[i].Column < filters[j].Column })
+	for _, f := range filters {
+		args = append(args, f.Value)
+		conds = append(conds, f.Column+" = "+d.placeholder(len(args)))
+	}
+	where = strings.Join(conds, " AND ")
+
+	var orders []string
+	for _, s := range q.Sort {
+		dir := "ASC"
+		if s.Desc {
+			dir = "DESC"
+		}
+		orders = append(orders, s.Column+" "+dir)
+	}
+	return where, args, strings.Join(orders, ", ")
+}
+
+// keysetCondition selects the rows after the cursor:
+// (a > x) OR (a = x AND b > y) OR ..., with < for descending columns
+func (d sqlDialect) keysetCondition(q model.ListQuery, args []interface{}) (string, []interface{}) {
+	var ors []string
+	for i, s := range q.Sort {
+		var ands []string
+		for j := 0; j < i; j++ {
+			args = append(args, q.Cursor[j])
+			ands = append(ands, q.Sort[j].Column+" = "+d.placeholder(len(args)))
+		}
+		op := ">"
+		if s.Desc {
+			op = "<"
+		}
+		args = append(args, q.Cursor[i])
+		ands = append(ands, s.Column+" "+op+" "+d.placeholder(len(args)))
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+// listRows runs a paged list query. columns must match the pointers returned
+// by fields; the sort expressions are selected after them to build the next cursor.
+// scope is a fixed condition applied before the client's filters ("" for none).
+func listRows[T any](db *sql.DB, d sqlDialect, table, scope, columns string, q model.ListQuery, fields func(*T) []interface{}) ([]T, model.ListMeta, error) {
+	meta := model.ListMeta{Limit: q.Limit, Page: q.Page}
+
+	where, args, order := d.listClauses(q)
+	if scope != "" {
+		if where != "" {
+			where = scope + " AND " + where
+		} else {
+			where = scope
+		}
+	}
+	countQuery := "SELECT COUNT(*) FROM " + table
+	if where != "" {
+		countQuery += " WHERE " + where
+	}
+	if err := db.QueryRow(countQuery, args...).Scan(&meta.Total); err != nil {
+		log.Errorf("Failed to count %s: %v", table, err)
+		return nil, meta, err
+	}
+
+	if q.Cursor != nil {
+		var keyset string
+		keyset, args = d.keysetCondition(q, args)
+		if where != "" {
+			where += " AND "
+		}
+		where += keyset
+	}
+
+	sortCols := make([]string, len(q.Sort))
+	for i, s := range q.Sort {
+		sortCols[i] = d.text(s.Column)
+	}
+
+	query := "SELECT " + columns + ", " + strings.Join(sortCols, ", ") + " FROM " + table
+	if where != "" {
+		query += " WHERE " + where
+	}
+	// One extra row tells whether another page follows
+	args = append(args, q.Limit+1)
+	query += " ORDER BY " + order + " LIMIT " + d.placeholder(len(args))
+	if q.Page > 0 {
+		args = append(args, (q.Page-1)*q.Limit)
+		query += " OFFSET " + d.placeholder(len(args))
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		log.Errorf("Failed to list %s: %v", table, err)
+		return nil, meta, err
+	}
+	defer rows.Close()
+
+	items := []T{}
+	var last []string
+	for rows.Next() {
+		var item T
+		sortValues := make([]string, len(q.Sort))
+		dest := fields(&item)
+		for i := range sortValues {
+			dest = append(dest, &sortValues[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			log.Errorf("Failed to scan %s row: %v", table, err)
+			return nil, meta, err
+		}
+		if len(items) == q.Limit {
+			// The extra row only signals that there is more
+			if q.Page > 0 {
+				meta.NextPage = q.Page + 1
+			} else {
+				meta.NextCursor = model.EncodeCursor(last)
+			}
+			break
+		}
+		items = append(items, item)
+		last = sortValues
+	}
+	if err := rows.Err(); err != nil {
+		log.Errorf("Error during %s rows iteration: %v", table, err)
+		return nil, meta, err
+	}
+
+	return items, meta, nil
+}
+
+// FirmListSpec lists the sort and filter fields of /firm/get
+var FirmListSpec = model.ListSpec{
+	IDColumn:    "id",
+	DefaultSort: "-id",
+	Sort: map[string]string{
+		"id":     "id",
+		"name_1": "name_1",
+		"plz":    "plz",
+		"ort":    "ort",
+	},
+	Filters: map[string]model.ListFilterField{
+		"kunde":     {Column: "kunde", Kind: model.FilterBool},
+		"lieferant": {Column: "lieferant", Kind: model.FilterBool},
+		"gesperrt":  {Column: "gesperrt", Kind: model.FilterBool},
+		"firma_typ": {Column: "firma_typ", Kind: model.FilterString},
+		"land":      {Column: "land", Kind: model.FilterString},
+		"plz":       {Column: "plz", Kind: model.FilterString},
+		"ort":       {Column: "ort", Kind: model.FilterString},
+	},
+}
+
+// ContactListSpec lists the sort and filter fields of /contact/get
+var ContactListSpec = model.ListSpec{
+	IDColumn:    "id",
+	DefaultSort: "-id",
+	Sort: map[string]string{
+		"id":       "id",
+		"vorname":  "vorname",
+		"nachname": "nachname",
+		"email":    "email",
+	},
+	Filters: map[string]model.ListFilterField{
+		"kontotyp":  {Column: "kontotyp", Kind: model.FilterString},
+		"abteilung": {Column: "abteilung", Kind: model.FilterString},
+		"position":  {Column: "position", Kind: model.FilterString},
+	},
+}
+
+// DeviceListSpec lists the sort and filter fields of /devices/list
+var DeviceListSpec = model.ListSpec{
+	IDColumn:    "id",
+	DefaultSort: "-id",
+	Sort: map[string]string{
+		"id":         "id",
+		"name":       "COALESCE(name, '')",
+		"hostname":   "COALESCE(hostname, '')",
+		"ip":         "COALESCE(ip, '')",
+		"department": "COALESCE(department, '')",
+	},
+	Filters: map[string]model.ListFilterField{
+		"department":            {Column: "department", Kind: model.FilterString},
+		"manufacturer":          {Column: "manufacturer", Kind: model.FilterString},
+		"domain":                {Column: "domain", Kind: model.FilterString},
+		"origin":                {Column: "origin", Kind: model.FilterString},
+		"location_text":         {Column: "location_text", Kind: model.FilterString},
+		"externally_accessible": {Column: "externally_accessible", Kind: model.FilterBool},
+	},
+}
+
+// DeviceLinkListSpec lists the sort and filter fields of /device_links/list
+var DeviceLinkListSpec = model.ListSpec{
+	IDColumn:    "id",
+	DefaultSort: "id",
+	Sort: map[string]string{
+		"id": "id",
+	},
+	Filters: map[string]model.ListFilterField{
+		"from_device_id": {Column: "from_device_id", Kind: model.FilterInt},
+		"to_device_id":   {Column: "to_device_id", Kind: model.FilterInt},
+	},
+}
+
+// ListFirms returns one page of firms
+func (db *MySQLDB) ListFirms(q model.ListQuery) ([]FirmParams, model.ListMeta, error) {
+	return listRows(db.DB, mysqlDialect, "firms", notDeleted(q.IncludeDeleted, "deleted_at"), `
+	id, anrede, name_1, COALESCE(name_2, ''), COALESCE(name_3, ''), COALESCE(straße, ''),
+	COALESCE(land, ''), plz, ort, telefon, email, COALESCE(website, ''), kunde,
+	lieferant, gesperrt, COALESCE(bemerkung, ''), COALESCE(firma_typ, ''), deleted_at, deleted_by`, q,
+		func(f *FirmParams) []interface{} {
+			return []interface{}{
+				&f.ID, &f.Anrede, &f.Name1, &f.Name2, &f.Name3,
+				&f.Straße, &f.Land, &f.PLZ, &f.Ort, &f.Telefon,
+				&f.Email, &f.Website, &f.Kunde, &f.Lieferant,
+				&f.Gesperrt, &f.Bemerkung, &f.FirmaTyp, &f.DeletedAt, &f.DeletedBy,
+			}
+		})
+}
+
+// ListContacts returns one page of contacts
+func (db *MySQLDB) ListContacts(q model.ListQuery) ([]ContactParams, model.ListMeta, error) {
+	return listRows(db.DB, mysqlDialect, "contacts", notDeleted(q.IncludeDeleted, "deleted_at"), `
+	id, COALESCE(anrede, ''), vorname, nachname, COALESCE(position, ''), COALESCE(telefon, ''),
+	COALESCE(mobil, ''), email, COALESCE(abteilung, ''), COALESCE(CAST(geburtstag AS CHAR), ''),
+	COALESCE(bemerkung, ''), kontotyp, deleted_at, deleted_by`, q,
+		func(c *ContactParams) []interface{} {
+			return []interface{}{
+				&c.ID, &c.Anrede, &c.Vorname, &c.Nachname, &c.Position,
+				&c.Telefon, &c.Mobil, &c.Email, &c.Abteilung,
+				&c.Geburtstag, &c.Bemerkung, &c.Kontotyp, &c.DeletedAt, &c.DeletedBy,
+			}
+		})
+}
+
+// ListDevices returns one page of devices
+func (p *PostgresDB) ListDevices(q model.ListQuery) ([]DeviceParams, model.ListMeta, error) {
+	return listRows(p.DB, postgresDialect, "devices", notDeleted(q.IncludeDeleted, "deleted_at"), deviceColumns, q, deviceFields)
+}
+
+// ListDeviceLinks returns one page of device links
+func (p *PostgresDB) ListDeviceLinks(q model.ListQuery) ([]DeviceLink, model.ListMeta, error) {
+	scope := liveDeviceLinks
+	if q.IncludeDeleted {
+		scope = ""
+	}
+	return listRows(p.DB, postgresDialect, "device_links", scope, "id, from_device_id, to_device_id", q,
+		func(l *DeviceLink) []interface{} {
+			return []interface{}{&l.ID, &l.FromDeviceID, &l.ToDeviceID}
+		})
+}
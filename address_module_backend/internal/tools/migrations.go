@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// devicePermissions guard the /devices and /device_links routes
+var devicePermissions = []string{
+	"view_devices",
+	"create_devices",
+	"edit_devices",
+	"delete_devices",
+	"manage_device_links",
+}
+
+// runMigration applies fn inside a transaction unless a migration with the
+// same name is already recorded in schema_migrations
+func (db *MySQLDB) runMigration(name string, fn func(tx *sql.Tx) error) error {
+	var applied int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE name = ?`, name).Scan(&applied); err != nil {
+		return fmt.Errorf("check migration %s: %w", name, err)
+	}
+	if applied > 0 {
+		return nil
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return fmt.Errorf("migration %s: %w", name, err)
+	}
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (name) VALUES (?)`, name); err != nil {
+		return fmt.Errorf("record migration %s: %w", name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	log.Infof("✅ Applied migration %s", name)
+	return nil
+}
+
+// migrateDevicePermissions runs once when the device routes start requiring
+// permissions. The routes used to be open to anyone, so every existing role
+// keeps read access through view_devices; the permissions that change devices
+// or their links only go to the admin role. It has to run after the
+// permissions are seeded and before the seed creates any roles, so a fresh
+// install records the migration without granting anything.
+func (db *MySQLDB) migrateDevicePermissions() error {
+	return db.runMigration("grant_device_permissions", func(tx *sql.Tx) error {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(devicePermissions)), ",")
+		args := []interface{}{"view_devices", "admin"}
+		for _, name := range devicePermissions {
+			args = append(args, name)
+		}
+
+		res, err := tx.Exec(`
+		INSERT IGNORE INTO role_permissions (role_id, permission_id)
+		SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+		WHERE p.name = ? OR (r.name = ? AND p.name IN (`+placeholders+`))`, args...)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			log.Infof("🔐 Granted device permissions to existing roles (%d grants)", n)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"address_module/internal/model"
+	"address_module/internal/notifier"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// EnqueueEmail stores a notification for the outbox dispatcher
+func (db *MySQLDB) EnqueueEmail(email model.OutboxEmail) (int64, error) {
+	if email.NextAttemptAt.IsZero() {
+		email.NextAttemptAt = time.Now()
+	}
+
+	result, err := db.DB.Exec(`
+	INSERT INTO email_outbox (ticket_id, event, recipient, subject, body, status, next_attempt_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		email.TicketID, email.Event, email.Recipient, email.Subject, email.Body,
+		model.EmailStatusPending, email.NextAttemptAt.UTC())
+	if err != nil {
+		log.Error("Failed to enqueue email: ", err)
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetDueEmails returns pending emails whose next attempt is due, oldest first
+func (db *MySQLDB) GetDueEmails(now time.Time, limit int) ([]model.OutboxEmail, error) {
+	rows, err := db.DB.Query(`
+	SELECT id, ticket_id, event, recipient, subject, body, status, attempts,
+	       COALESCE(last_error, ''), next_attempt_at, created_at, sent_at
+	FROM email_outbox
+	WHERE status = ? AND next_attempt_at <= ?
+	ORDER BY next_attempt_at, id
+	LIMIT ?`, model.EmailStatusPending, now.UTC(), limit)
+	if err != nil {
+		log.Error("Failed to query due emails: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	emails := []model.OutboxEmail{}
+	for rows.Next() {
+		var e model.OutboxEmail
+		if err := rows.Scan(&e.ID, &e.TicketID, &e.Event, &e.Recipient, &e.Subject, &e.Body, &e.Status,
+			&e.Attempts, &e.LastError, &e.NextAttemptAt, &e.CreatedAt, &e.SentAt); err != nil {
+			return nil, err
+		}
+		emails = append(emails, e)
+	}
+	return emails, rows.Err()
+}
+
+// redactedBody replaces the body of password reset and verification emails
+// once they leave the queue. Their links carry the plaintext token, which must
+// not stay readable in the outbox after delivery or after giving up.
+const redactedBody = `body = CASE WHEN event IN ('` + notifier.EventPasswordReset + `', '` + notifier.EventEmailVerification + `')
+	THEN '[redacted]' ELSE body END`
+
+// MarkEmailSent records a successful delivery
+func (db *MySQLDB) MarkEmailSent(id int64, at time.Time) error {
+	_, err := db.DB.Exec(`
+	UPDATE email_outbox SET status = ?, attempts = attempts + 1, last_error = NULL, sent_at = ?, `+redactedBody+`
+	WHERE id = ?`, model.EmailStatusSent, at.UTC(), id)
+	if err != nil {
+		log.Error("Failed to mark email as sent: ", err)
+		return err
+	}
+	return nil
+}
+
+// MarkEmailFailed records a failed attempt. With a zero retryAt the email is
+// given up on; otherwise it stays pending until retryAt.
+func (db *MySQLDB) MarkEmailFailed(id int64, sendErr error, retryAt time.Time) error {
+	status, next, body := model.EmailStatusPending, retryAt.UTC(), `body = body`
+	if retryAt.IsZero() {
+		status, next, body = model.EmailStatusFailed, time.Now().UTC(), redactedBody
+	}
+
+	_, err := db.DB.Exec(`
+	UPDATE email_outbox SET status = ?, attempts = attempts + 1, last_error = ?, next_attempt_at = ?, `+body+`
+	WHERE id = ?`, status, sendErr.Error(), next, id)
+	if err != nil {
+		log.Error("Failed to mark email as failed: ", err)
+		return err
+	}
+	return nil
+}
+
+// GetTicketRecipients returns the requester and assignee of a ticket with their email addresses
+func (db *MySQLDB) GetTicketRecipients(ticketID int64) (*model.TicketRecipients, error) {
+	var r model.TicketRecipients
+	err := db.DB.QueryRow(`
+	SELECT t.contact_id, COALESCE(CONCAT_WS(' ', c.vorname, c.nachname), ''), COALESCE(c.email, ''),
+	       t.assignee_id, COALESCE(u.username, ''), COALESCE(u.email, '')
+	FROM tickets t
+	LEFT JOIN contacts c ON c.id = t.contact_id
+	LEFT JOIN users u ON u.id = t.assignee_id
+	WHERE t.id = ?`, ticketID).Scan(
+		&r.ContactID, &r.ContactName, &r.ContactEmail, &r.AssigneeID, &r.AssigneeName, &r.AssigneeEmail)
+	if err != nil {
+		log.Error("Failed to load ticket recipients: ", err)
+		return nil, err
+	}
+	return &r, nil
+}
@@ -0,0 +1,18 @@
+package tools
+
+// GetPermissionVersion returns the current grant version. Tokens carrying an
+// older version were issued before a role or permission change.
+func (db *MySQLDB) GetPermissionVersion() (int64, error) {
+	var version int64
+	err := db.DB.QueryRow(`SELECT version FROM permission_version WHERE id = 1`).Scan(&version)
+	return version, err
+}
+
+// BumpPermissionVersion marks every issued permission set as stale and
+// returns the new version
+func (db *MySQLDB) BumpPermissionVersion() (int64, error) {
+	if _, err := db.DB.Exec(`UPDATE permission_version SET version = version + 1 WHERE id = 1`); err != nil {
+		return 0, err
+	}
+	return db.GetPermissionVersion()
+}
@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"database/sql"
+	"os"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// PoolConfig limits the connection pool shared by all requests
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// PoolConfigFromEnv reads the pool limits from <prefix>_MAX_OPEN_CONNS,
+// <prefix>_MAX_IDLE_CONNS, <prefix>_CONN_MAX_LIFETIME and
+// <prefix>_CONN_MAX_IDLE_TIME, e.g. MYSQL_MAX_OPEN_CONNS
+func PoolConfigFromEnv(prefix string) PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    poolEnvInt(prefix+"_MAX_OPEN_CONNS", 25),
+		MaxIdleConns:    poolEnvInt(prefix+"_MAX_IDLE_CONNS", 10),
+		ConnMaxLifetime: poolEnvDuration(prefix+"_CONN_MAX_LIFETIME", 30*time.Minute),
+		ConnMaxIdleTime: poolEnvDuration(prefix+"_CONN_MAX_IDLE_TIME", 5*time.Minute),
+	}
+}
+
+// Apply sets the limits on an open pool
+func (c PoolConfig) Apply(db *sql.DB) {
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(c.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+}
+
+func poolEnvInt(name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Warnf("Invalid %s=%q, using %d", name, v, def)
+		return def
+	}
+	return n
+}
+
+func poolEnvDuration(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		log.Warnf("Invalid %s=%q, using %s", name, v, def)
+		return def
+	}
+	return d
+}
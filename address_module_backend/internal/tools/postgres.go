@@ -52,6 +52,7 @@ func NewPostgresDatabase(maxRetries int, delay time.Duration) (*PostgresDB, erro
 		}
 
 		log.Infof("✅ Connected to PostgreSQL DB on attempt %d", attempt)
+		PoolConfigFromEnv("DEVICE_DB").Apply(db)
 		return &PostgresDB{DB: db}, nil
 	}
 
@@ -65,6 +66,30 @@ func (p *PostgresDB) Close() error {
 	return nil
 }
 
+// SetupDatabase applies the schema changes the backend made on top of
+// db_init_device/init.sql. The device search index is optional.
+func (p *PostgresDB) SetupDatabase() error {
+	_, err := p.DB.Exec(`
+		ALTER TABLE devices
+			ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP NULL,
+			ADD COLUMN IF NOT EXISTS deleted_by BIGINT NULL;`)
+	if err != nil {
+		log.Error("Failed to migrate devices table: ", err)
+		return err
+	}
+
+	if err := p.SetupDeviceHistoryTable(); err != nil {
+		return err
+	}
+
+	if err := p.SetupSearchIndex(); err != nil {
+		log.Warnf("Device search will run without its index: %v", err)
+	}
+
+	log.Info("Device database setup completed")
+	return nil
+}
+
 // Simplified: JSONB fields now stored as TEXT
 type DeviceParams struct {
 	ID                    int64      `json:"id"`
@@ -107,10 +132,38 @@ type DeviceParams struct {
 	PatchLocation         string     `json:"patch_location"`
 	Documents             string     `json:"documents"`
 	CreatedAt             *time.Time `json:"created_at,omitempty"`
+	DeletedAt             *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy             *int64     `json:"deleted_by,omitempty"`
+}
+
+// deviceColumns lists the devices columns in the order deviceFields scans them
+const deviceColumns = `id, name, hostname, ip, domain, manufacturer, model_type,
+	serial_numbers, mac, description, equipment, function, settings,
+	device_link, commissioning_date, origin, warranty_service_number,
+	warranty_until, licenses, location_text, department, internal_contact,
+	external_contact, map_link, software_interfaces, backup_method,
+	backup_file_link, software_asset, password_link, internal_access,
+	external_access, misc_links, externally_accessible, restart_how,
+	restart_notes, restart_coordination, network_connection, patch_location,
+	documents, created_at, deleted_at, deleted_by`
+
+// deviceFields returns the scan destinations matching deviceColumns
+func deviceFields(d *DeviceParams) []interface{} {
+	return []interface{}{
+		&d.ID, &d.Name, &d.Hostname, &d.IP, &d.Domain, &d.Manufacturer, &d.ModelType,
+		&d.SerialNumbers, &d.MAC, &d.Description, &d.Equipment, &d.Function, &d.Settings,
+		&d.DeviceLink, &d.CommissioningDate, &d.Origin, &d.WarrantyServiceNumber,
+		&d.WarrantyUntil, &d.Licenses, &d.LocationText, &d.Department, &d.InternalContact,
+		&d.ExternalContact, &d.MapLink, &d.SoftwareInterfaces, &d.BackupMethod,
+		&d.BackupFileLink, &d.SoftwareAsset, &d.PasswordLink, &d.InternalAccess,
+		&d.ExternalAccess, &d.MiscLinks, &d.ExternallyAccessible, &d.RestartHow,
+		&d.RestartNotes, &d.RestartCoordination, &d.NetworkConnection, &d.PatchLocation,
+		&d.Documents, &d.CreatedAt, &d.DeletedAt, &d.DeletedBy,
+	}
 }
 
 func (p *PostgresDB) GetAllDevices() ([]DeviceParams, error) {
-	rows, err := p.DB.Query(`SELECT * FROM devices ORDER BY id DESC;`)
+	rows, err := p.DB.Query(`SELECT ` + deviceColumns + ` FROM devices WHERE deleted_at IS NULL ORDER BY id DESC;`)
 	if err != nil {
 		return nil, err
 	}
@@ -119,18 +172,7 @@ func (p *PostgresDB) GetAllDevices() ([]DeviceParams, error) {
 	var devices []DeviceParams
 	for rows.Next() {
 		var d DeviceParams
-		err := rows.Scan(
-			&d.ID, &d.Name, &d.Hostname, &d.IP, &d.Domain, &d.Manufacturer, &d.ModelType,
-			&d.SerialNumbers, &d.MAC, &d.Description, &d.Equipment, &d.Function, &d.Settings,
-			&d.DeviceLink, &d.CommissioningDate, &d.Origin, &d.WarrantyServiceNumber,
-			&d.WarrantyUntil, &d.Licenses, &d.LocationText, &d.Department, &d.InternalContact,
-			&d.ExternalContact, &d.MapLink, &d.SoftwareInterfaces, &d.BackupMethod,
-			&d.BackupFileLink, &d.SoftwareAsset, &d.PasswordLink, &d.InternalAccess,
-			&d.ExternalAccess, &d.MiscLinks, &d.ExternallyAccessible, &d.RestartHow,
-			&d.RestartNotes, &d.RestartCoordination, &d.NetworkConnection, &d.PatchLocation,
-			&d.Documents, &d.CreatedAt,
-		)
-		if err != nil {
+		if err := rows.Scan(deviceFields(&d)...); err != nil {
 			return nil, err
 		}
 		devices = append(devices, d)
@@ -139,20 +181,15 @@ func (p *PostgresDB) GetAllDevices() ([]DeviceParams, error) {
 	return devices, nil
 }
 
-func (p *PostgresDB) GetDeviceByID(id int64) (*DeviceParams, error) {
+// GetDeviceByID fetches a device; soft-deleted devices only with includeDeleted
+func (p *PostgresDB) GetDeviceByID(id int64, includeDeleted bool) (*DeviceParams, error) {
+	query := `SELECT ` + deviceColumns + ` FROM devices WHERE id = $1`
+	if !includeDeleted {
+		query += ` AND deleted_at IS NULL`
+	}
+
 	var d DeviceParams
-	err := p.DB.QueryRow(`SELECT * FROM devices WHERE id = $1`, id).Scan(
-		&d.ID, &d.Name, &d.Hostname, &d.IP, &d.Domain, &d.Manufacturer, &d.ModelType,
-		&d.SerialNumbers, &d.MAC, &d.Description, &d.Equipment, &d.Function, &d.Settings,
-		&d.DeviceLink, &d.CommissioningDate, &d.Origin, &d.WarrantyServiceNumber,
-		&d.WarrantyUntil, &d.Licenses, &d.LocationText, &d.Department, &d.InternalContact,
-		&d.ExternalContact, &d.MapLink, &d.SoftwareInterfaces, &d.BackupMethod,
-		&d.BackupFileLink, &d.SoftwareAsset, &d.PasswordLink, &d.InternalAccess,
-		&d.ExternalAccess, &d.MiscLinks, &d.ExternallyAccessible, &d.RestartHow,
-		&d.RestartNotes, &d.RestartCoordination, &d.NetworkConnection, &d.PatchLocation,
-		&d.Documents, &d.CreatedAt,
-	)
-	if err != nil {
+	if err := p.DB.QueryRow(query, id).Scan(deviceFields(&d)...); err != nil {
 		return nil, err
 	}
 	return &d, nil
@@ -195,7 +232,34 @@ func (p *PostgresDB) InsertDevice(device *DeviceParams) (int64, error) {
 	return id, err
 }
 
-func (p *PostgresDB) UpdateDevice(device *DeviceParams) error {
+// UpdateDevice overwrites a device and records the new state in its history;
+// sql.ErrNoRows if the device does not exist or is soft-deleted
+func (p *PostgresDB) UpdateDevice(device *DeviceParams, changedBy *int64) error {
+	return p.updateDevice(device, changedBy, nil)
+}
+
+// updateDevice runs the update and the history bookkeeping in one transaction.
+// revertedFrom is set when the new state was copied from an older version.
+func (p *PostgresDB) updateDevice(device *DeviceParams, changedBy *int64, revertedFrom *int) error {
+	tx, err := p.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return err
+	}
+
+	var current DeviceParams
+	err = tx.QueryRow(`SELECT `+deviceColumns+` FROM devices WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, device.ID).
+		Scan(deviceFields(&current)...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	version, err := ensureBaselineVersion(tx, &current)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	query := `
 	UPDATE devices SET
 		name = $1, hostname = $2, ip = $3, domain = $4, manufacturer = $5, model_type = $6, serial_numbers = $7,
@@ -209,7 +273,7 @@ func (p *PostgresDB) UpdateDevice(device *DeviceParams) error {
 	WHERE id = $39;
 	`
 
-	_, err := p.DB.Exec(query,
+	_, err = tx.Exec(query,
 		device.Name, device.Hostname, device.IP, device.Domain, device.Manufacturer, device.ModelType, device.SerialNumbers,
 		device.MAC, device.Description, device.Equipment, device.Function, device.Settings, device.DeviceLink,
 		device.CommissioningDate, device.Origin, device.WarrantyServiceNumber, device.WarrantyUntil,
@@ -220,7 +284,29 @@ func (p *PostgresDB) UpdateDevice(device *DeviceParams) error {
 		device.RestartCoordination, device.NetworkConnection, device.PatchLocation, device.Documents,
 		device.ID,
 	)
-	return err
+	if err != nil {
+		log.Error("Failed to update device: ", err)
+		tx.Rollback()
+		return err
+	}
+
+	var updated DeviceParams
+	if err := tx.QueryRow(`SELECT `+deviceColumns+` FROM devices WHERE id = $1`, device.ID).Scan(deviceFields(&updated)...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if len(deviceChanges(&current, &updated)) > 0 {
+		if err := insertDeviceVersion(tx, &updated, version+1, changedBy, revertedFrom); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit device update: ", err)
+		return err
+	}
+	return nil
 }
 
 func (p *PostgresDB) DeleteDevice(id int64) error {
@@ -228,6 +314,32 @@ func (p *PostgresDB) DeleteDevice(id int64) error {
 	return err
 }
 
+// GetDevicesWithWarrantyEnding returns devices whose warranty ends between from and to (inclusive dates)
+func (p *PostgresDB) GetDevicesWithWarrantyEnding(from, to time.Time) ([]DeviceParams, error) {
+	rows, err := p.DB.Query(`
+		SELECT id, COALESCE(name, ''), COALESCE(hostname, ''), COALESCE(ip, ''),
+		       COALESCE(location_text, ''), COALESCE(warranty_service_number, ''), warranty_until
+		FROM devices
+		WHERE warranty_until BETWEEN $1::date AND $2::date AND deleted_at IS NULL
+		ORDER BY warranty_until, id;`,
+		from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devices []DeviceParams
+	for rows.Next() {
+		var d DeviceParams
+		if err := rows.Scan(&d.ID, &d.Name, &d.Hostname, &d.IP, &d.LocationText,
+			&d.WarrantyServiceNumber, &d.WarrantyUntil); err != nil {
+			return nil, err
+		}
+		devices = append(devices, d)
+	}
+	return devices, rows.Err()
+}
+
 // ---- Device Links ----
 
 type DeviceLink struct {
@@ -236,8 +348,16 @@ type DeviceLink struct {
 	ToDeviceID   int64 `json:"to_device_id"`
 }
 
+// liveDeviceLinks limits device_links to links between devices that are not soft-deleted
+const liveDeviceLinks = `NOT EXISTS (
+	SELECT 1 FROM devices d
+	WHERE d.id IN (device_links.from_device_id, device_links.to_device_id) AND d.deleted_at IS NOT NULL)`
+
 func (p *PostgresDB) GetAllDeviceLinks() ([]DeviceLink, error) {
-	rows, err := p.DB.Query(`SELECT id, from_device_id, to_device_id FROM device_links ORDER BY id;`)
+	rows, err := p.DB.Query(`
+		SELECT id, from_device_id, to_device_id FROM device_links
+		WHERE ` + liveDeviceLinks + `
+		ORDER BY id;`)
 	if err != nil {
 		return nil, err
 	}
@@ -262,7 +382,11 @@ func (p *PostgresDB) InsertDeviceLink(link *DeviceLink) (int64, error) {
 }
 
 func (p *PostgresDB) GetLinksForDevice(deviceID int64) ([]DeviceLink, error) {
-	rows, err := p.DB.Query(`SELECT id, from_device_id, to_device_id FROM device_links WHERE from_device_id = $1;`, deviceID)
+	rows, err := p.DB.Query(`
+		SELECT l.id, l.from_device_id, l.to_device_id
+		FROM device_links l
+		JOIN devices d ON d.id = l.to_device_id
+		WHERE l.from_device_id = $1 AND d.deleted_at IS NULL;`, deviceID)
 	if err != nil {
 		return nil, err
 	}
@@ -279,6 +403,17 @@ func (p *PostgresDB) GetLinksForDevice(deviceID int64) ([]DeviceLink, error) {
 	return links, nil
 }
 
+// GetDeviceLinkByID fetches a single device link
+func (p *PostgresDB) GetDeviceLinkByID(id int64) (*DeviceLink, error) {
+	var l DeviceLink
+	err := p.DB.QueryRow(`SELECT id, from_device_id, to_device_id FROM device_links WHERE id = $1;`, id).
+		Scan(&l.ID, &l.FromDeviceID, &l.ToDeviceID)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
 func (p *PostgresDB) UpdateDeviceLink(link *DeviceLink) error {
 	query := `
 		UPDATE device_links
@@ -348,7 +483,7 @@ func RunPostgresDeviceCRUDTests(pgDB *PostgresDB) error {
 	fmt.Printf("✅ Created device with ID: %d\n", id)
 
 	// READ
-	device, err := pgDB.GetDeviceByID(id)
+	device, err := pgDB.GetDeviceByID(id, false)
 	if err != nil {
 		return fmt.Errorf("❌ GetDeviceByID failed: %v", err)
 	}
@@ -356,7 +491,7 @@ func RunPostgresDeviceCRUDTests(pgDB *PostgresDB) error {
 
 	// UPDATE
 	device.Description = "UPDATED: Test device after refactor"
-	if err := pgDB.UpdateDevice(device); err != nil {
+	if err := pgDB.UpdateDevice(device, nil); err != nil {
 		return fmt.Errorf("❌ UpdateDevice failed: %v", err)
 	}
 	fmt.Println("🔄 Updated device description successfully.")
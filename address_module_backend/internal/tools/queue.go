@@ -0,0 +1,324 @@
+package tools
+
+import (
+	"address_module/internal/model"
+	"database/sql"
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrNotQueueMember is returned when a ticket is assigned to a user outside its queue
+var ErrNotQueueMember = errors.New("assignee is not a member of the queue")
+
+func loadQueue(q queryer, where string, args ...interface{}) (*model.Queue, error) {
+	var queue model.Queue
+	err := q.QueryRow(`
+	SELECT id, name, COALESCE(description, ''), assignment_mode, required_role_id
+	FROM queues `+where, args...).Scan(
+		&queue.ID, &queue.Name, &queue.Description, &queue.AssignmentMode, &queue.RequiredRoleID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Soft-deleted users stay in queue_members so a restore brings them back,
+	// but they are neither listed nor accepted as assignees
+	rows, err := q.Query(`
+	SELECT qm.user_id FROM queue_members qm
+	JOIN users u ON u.id = qm.user_id AND u.deleted_at IS NULL
+	WHERE qm.queue_id = ?
+	ORDER BY qm.user_id`, queue.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	queue.MemberIDs = []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		queue.MemberIDs = append(queue.MemberIDs, id)
+	}
+	return &queue, rows.Err()
+}
+
+func insertQueueMembers(tx *sql.Tx, queueID int64, userIDs []int64) error {
+	for _, userID := range userIDs {
+		if _, err := tx.Exec(`INSERT IGNORE INTO queue_members (queue_id, user_id) VALUES (?, ?)`, queueID, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InsertQueue inserts a queue with its members and returns its ID
+func (db *MySQLDB) InsertQueue(queue model.Queue) (int64, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return 0, err
+	}
+
+	result, err := tx.Exec(`
+	INSERT INTO queues (name, description, assignment_mode, required_role_id)
+	VALUES (?, ?, ?, ?)`, queue.Name, queue.Description, queue.AssignmentMode, queue.RequiredRoleID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to insert queue: ", err)
+		return 0, err
+	}
+
+	queueID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to get queue ID: ", err)
+		return 0, err
+	}
+
+	if err := insertQueueMembers(tx, queueID, queue.MemberIDs); err != nil {
+		tx.Rollback()
+		log.Error("Failed to insert queue members: ", err)
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return 0, err
+	}
+
+	log.Info("Queue inserted successfully with ID: ", queueID)
+	return queueID, nil
+}
+
+// GetQueueByID fetches a queue and its members
+func (db *MySQLDB) GetQueueByID(id int64) (*model.Queue, error) {
+	queue, err := loadQueue(db.DB, `WHERE id = ?`, id)
+	if err != nil {
+		log.Error("Failed to get queue: ", err)
+		return nil, err
+	}
+	return queue, nil
+}
+
+// GetAllQueues retrieves all queues with their members
+func (db *MySQLDB) GetAllQueues() ([]model.Queue, error) {
+	rows, err := db.DB.Query(`SELECT id FROM queues ORDER BY name`)
+	if err != nil {
+		log.Error("Failed to query queues: ", err)
+		return nil, err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	queues := make([]model.Queue, 0, len(ids))
+	for _, id := range ids {
+		queue, err := db.GetQueueByID(id)
+		if err != nil {
+			return nil, err
+		}
+		queues = append(queues, *queue)
+	}
+	return queues, nil
+}
+
+// UpdateQueue replaces a queue definition including its members
+func (db *MySQLDB) UpdateQueue(queue model.Queue) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return err
+	}
+
+	var exists int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM queues WHERE id = ?`, queue.ID).Scan(&exists); err != nil || exists == 0 {
+		tx.Rollback()
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.Exec(`
+	UPDATE queues SET name = ?, description = ?, assignment_mode = ?, required_role_id = ?
+	WHERE id = ?`, queue.Name, queue.Description, queue.AssignmentMode, queue.RequiredRoleID, queue.ID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to update queue: ", err)
+		return err
+	}
+
+	// Deleted members are not listed, so the client cannot send them back; keep them
+	if _, err := tx.Exec(`
+	DELETE qm FROM queue_members qm
+	JOIN users u ON u.id = qm.user_id
+	WHERE qm.queue_id = ? AND u.deleted_at IS NULL`, queue.ID); err != nil {
+		tx.Rollback()
+		log.Error("Failed to clear queue members: ", err)
+		return err
+	}
+	if err := insertQueueMembers(tx, queue.ID, queue.MemberIDs); err != nil {
+		tx.Rollback()
+		log.Error("Failed to insert queue members: ", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	log.Info("Queue updated successfully")
+	return nil
+}
+
+// DeleteQueue deletes a queue; its tickets keep their assignee but leave the queue
+func (db *MySQLDB) DeleteQueue(id int64) error {
+	result, err := db.DB.Exec(`DELETE FROM queues WHERE id = ?`, id)
+	if err != nil {
+		log.Error("Failed to delete queue: ", err)
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	log.Info("Queue deleted successfully")
+	return nil
+}
+
+// pickQueueAssignee chooses the next member of an automatic queue. Only members
+// that are not deleted and hold the queue's required role (if any) are considered. The queue row must
+// be locked by the caller so concurrent assignments do not pick the same turn.
+func pickQueueAssignee(tx *sql.Tx, queue *model.Queue, lastAssigned *int64) (*int64, error) {
+	eligible := `
+	SELECT qm.user_id
+	FROM queue_members qm
+	JOIN users u ON u.id = qm.user_id AND u.deleted_at IS NULL
+	WHERE qm.queue_id = ?
+	  AND (? IS NULL OR EXISTS (
+	      SELECT 1 FROM user_roles ur WHERE ur.user_id = qm.user_id AND ur.role_id = ?))`
+	args := []interface{}{queue.ID, queue.RequiredRoleID, queue.RequiredRoleID}
+
+	var query string
+	switch queue.AssignmentMode {
+	case model.QueueModeRoundRobin:
+		// The member after the last one picked, wrapping around to the first
+		last := int64(0)
+		if lastAssigned != nil {
+			last = *lastAssigned
+		}
+		query = `SELECT user_id FROM (` + eligible + `) m ORDER BY user_id <= ?, user_id LIMIT 1`
+		args = append(args, last)
+	case model.QueueModeLeastOpen:
+		query = `
+		SELECT m.user_id FROM (` + eligible + `) m
+		LEFT JOIN tickets t ON t.assignee_id = m.user_id AND ` + openTicketFilter("t") + `
+		GROUP BY m.user_id
+		ORDER BY COUNT(t.id), m.user_id
+		LIMIT 1`
+	default:
+		return nil, nil
+	}
+
+	var userID int64
+	err := tx.QueryRow(query, args...).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &userID, nil
+}
+
+// AssignTicket puts a ticket into a queue and assigns it. With a nil assignee,
+// an automatic queue picks one; a manual queue leaves the ticket unassigned.
+// An explicit assignee must be a member of the queue. It returns the ticket's
+// resulting assignee.
+func (db *MySQLDB) AssignTicket(ticketID int64, queueID, assigneeID *int64) (*int64, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return nil, err
+	}
+
+	var current int64
+	if err := tx.QueryRow(`SELECT id FROM tickets WHERE id = ? FOR UPDATE`, ticketID).Scan(&current); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if queueID != nil {
+		var lastAssigned *int64
+		if err := tx.QueryRow(`SELECT last_assigned_user_id FROM queues WHERE id = ? FOR UPDATE`, *queueID).Scan(&lastAssigned); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		queue, err := loadQueue(tx, `WHERE id = ?`, *queueID)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		if assigneeID != nil {
+			member := false
+			for _, id := range queue.MemberIDs {
+				member = member || id == *assigneeID
+			}
+			if !member {
+				tx.Rollback()
+				return nil, ErrNotQueueMember
+			}
+		} else if queue.AutoAssigns() {
+			assigneeID, err = pickQueueAssignee(tx, queue, lastAssigned)
+			if err != nil {
+				tx.Rollback()
+				log.Error("Failed to pick queue assignee: ", err)
+				return nil, err
+			}
+			if assigneeID != nil {
+				if _, err := tx.Exec(`UPDATE queues SET last_assigned_user_id = ? WHERE id = ?`, *assigneeID, queue.ID); err != nil {
+					tx.Rollback()
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE tickets SET queue_id = ?, assignee_id = ? WHERE id = ?`, queueID, assigneeID, ticketID); err != nil {
+		tx.Rollback()
+		log.Error("Failed to assign ticket: ", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"ticket_id": ticketID,
+		"queue_id":  queueID,
+		"assignee":  assigneeID,
+	}).Info("Ticket assigned")
+	return assigneeID, nil
+}
+
+// GetTicketsByAssignee returns the tickets assigned to a user, newest first.
+// Tickets in a closed status of their workflow are left out unless includeClosed is set.
+func (db *MySQLDB) GetTicketsByAssignee(userID int64, includeClosed bool) ([]model.Ticket, error) {
+	if includeClosed {
+		return db.getTicketsWhere(`WHERE assignee_id = ?`, userID)
+	}
+	return db.getTicketsWhere(`WHERE assignee_id = ? AND `+openTicketFilter("tickets"), userID)
+}
@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"address_module/internal/model"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SchedulerRun is the last recorded execution of a background job
+type SchedulerRun struct {
+	JobName    string    `json:"job_name"`
+	LastRunAt  time.Time `json:"last_run_at"`
+	DurationMs int64     `json:"last_duration_ms"`
+	Status     string    `json:"last_status"` // "ok" or "failed"
+	Error      string    `json:"last_error,omitempty"`
+}
+
+// RecordSchedulerRun stores the outcome of a job run, replacing the previous one
+func (db *MySQLDB) RecordSchedulerRun(jobName string, startedAt time.Time, duration time.Duration, runErr error) error {
+	status, errText := "ok", sql.NullString{}
+	if runErr != nil {
+		status = "failed"
+		errText = sql.NullString{String: runErr.Error(), Valid: true}
+	}
+
+	_, err := db.DB.Exec(`
+	INSERT INTO scheduler_runs (job_name, last_run_at, last_duration_ms, last_status, last_error)
+	VALUES (?, ?, ?, ?, ?)
+	ON DUPLICATE KEY UPDATE
+		last_run_at = VALUES(last_run_at),
+		last_duration_ms = VALUES(last_duration_ms),
+		last_status = VALUES(last_status),
+		last_error = VALUES(last_error)`,
+		jobName, startedAt.UTC(), duration.Milliseconds(), status, errText)
+	if err != nil {
+		log.Error("Failed to record scheduler run: ", err)
+		return err
+	}
+	return nil
+}
+
+// GetSchedulerRun returns the last recorded run of a job, or sql.ErrNoRows if it never ran
+func (db *MySQLDB) GetSchedulerRun(jobName string) (*SchedulerRun, error) {
+	var run SchedulerRun
+	err := db.DB.QueryRow(`
+	SELECT job_name, last_run_at, last_duration_ms, last_status, COALESCE(last_error, '')
+	FROM scheduler_runs WHERE job_name = ?`, jobName).Scan(
+		&run.JobName, &run.LastRunAt, &run.DurationMs, &run.Status, &run.Error)
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// EscalateTickets marks open tickets whose first-response or resolution deadline
+// lies before `until` as escalated and leaves an internal note on each. Tickets
+// that are already escalated are skipped, so running it repeatedly is harmless.
+func (db *MySQLDB) EscalateTickets(now, until time.Time) ([]int64, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return nil, err
+	}
+
+	rows, err := tx.Query(`
+	SELECT id, first_response_due_at, resolution_due_at, first_responded_at
+	FROM tickets
+	WHERE escalated_at IS NULL
+	  AND ((first_responded_at IS NULL AND first_response_due_at < ?) OR resolution_due_at < ?)
+	  AND `+openTicketFilter("tickets")+`
+	FOR UPDATE`, until.UTC(), until.UTC())
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to query tickets to escalate: ", err)
+		return nil, err
+	}
+
+	type pending struct {
+		id   int64
+		note string
+	}
+	var tickets []pending
+	for rows.Next() {
+		var id int64
+		var responseDue, resolutionDue, respondedAt *time.Time
+		if err := rows.Scan(&id, &responseDue, &resolutionDue, &respondedAt); err != nil {
+			rows.Close()
+			tx.Rollback()
+			return nil, err
+		}
+
+		var reasons []string
+		if respondedAt == nil && responseDue != nil && responseDue.Before(until) {
+			reasons = append(reasons, "first response due "+responseDue.UTC().Format(time.RFC3339))
+		}
+		if resolutionDue != nil && resolutionDue.Before(until) {
+			reasons = append(reasons, "resolution due "+resolutionDue.UTC().Format(time.RFC3339))
+		}
+		tickets = append(tickets, pending{id: id, note: "SLA escalation: " + strings.Join(reasons, ", ")})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	ids := make([]int64, 0, len(tickets))
+	for _, t := range tickets {
+		if _, err := tx.Exec(`UPDATE tickets SET escalated_at = ? WHERE id = ?`, now.UTC(), t.id); err != nil {
+			tx.Rollback()
+			log.Error("Failed to escalate ticket: ", err)
+			return nil, err
+		}
+		if _, err := tx.Exec(`
+		INSERT INTO ticket_comments (ticket_id, author_id, body, internal)
+		VALUES (?, NULL, ?, TRUE)`, t.id, t.note); err != nil {
+			tx.Rollback()
+			log.Error("Failed to add escalation note: ", err)
+			return nil, err
+		}
+		ids = append(ids, t.id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// CreateWarrantyTicket opens a ticket for a device whose warranty is about to
+// expire. Each (device, warranty date) pair gets at most one ticket; if a notice
+// already exists it returns 0 without creating anything.
+func (db *MySQLDB) CreateWarrantyTicket(device DeviceParams) (int64, error) {
+	if device.WarrantyUntil == nil {
+		return 0, fmt.Errorf("device %d has no warranty date", device.ID)
+	}
+	until := device.WarrantyUntil.Format("2006-01-02")
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return 0, err
+	}
+
+	result, err := tx.Exec(`
+	INSERT IGNORE INTO warranty_notices (device_id, warranty_until)
+	VALUES (?, ?)`, device.ID, until)
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to record warranty notice: ", err)
+		return 0, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		tx.Rollback()
+		return 0, nil
+	}
+
+	wf, err := loadWorkflow(tx, `WHERE is_default = TRUE ORDER BY id LIMIT 1`)
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to load default workflow: ", err)
+		return 0, err
+	}
+
+	description := fmt.Sprintf("The warranty of device %q (ID %d) expires on %s.", device.Name, device.ID, until)
+	if device.WarrantyServiceNumber != "" {
+		description += "\nWarranty service number: " + device.WarrantyServiceNumber
+	}
+	ticket := model.Ticket{
+		Title:       fmt.Sprintf("Warranty of %s expires on %s", device.Name, until),
+		Description: description,
+		Status:      wf.InitialStatus,
+		Priority:    model.TicketPriorityNormal,
+		WorkflowID:  &wf.ID,
+	}
+
+	result, err = tx.Exec(ticketInsertQuery, ticketInsertArgs(ticket)...)
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to insert warranty ticket: ", err)
+		return 0, err
+	}
+	ticketID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to get ticket ID: ", err)
+		return 0, err
+	}
+
+	if err := insertTicketDevices(tx, ticketID, []model.TicketDevice{TicketDeviceSnapshot(&device)}); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if _, err := tx.Exec(`
+	UPDATE warranty_notices SET ticket_id = ?
+	WHERE device_id = ? AND warranty_until = ?`, ticketID, device.ID, until); err != nil {
+		tx.Rollback()
+		log.Error("Failed to link warranty notice to ticket: ", err)
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return 0, err
+	}
+
+	log.WithFields(log.Fields{
+		"ticket_id": ticketID,
+		"device_id": device.ID,
+	}).Info("Warranty ticket created")
+	return ticketID, nil
+}
@@ -0,0 +1,140 @@
+package tools
+
+import (
+	"address_module/internal/model"
+	"strings"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// booleanModeQuery turns search terms into a MySQL boolean mode query where
+// every term is required and matched as a prefix. MySQL splits words at
+// punctuation, so "max@example.com" becomes +max* +example* +com*.
+func booleanModeQuery(terms []string) string {
+	var parts []string
+	for _, term := range terms {
+		for _, word := range strings.FieldsFunc(term, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+		}) {
+			parts = append(parts, "+"+word+"*")
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// searchMySQL runs one full-text query; the SELECT must return id, title, subtitle, score
+func (db *MySQLDB) searchMySQL(resultType, query, against string, limit int) ([]model.SearchResult, error) {
+	rows, err := db.DB.Query(query, against, against, limit)
+	if err != nil {
+		log.Errorf("Failed to search %ss: %v", resultType, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []model.SearchResult
+	for rows.Next() {
+		r := model.SearchResult{Type: resultType}
+		if err := rows.Scan(&r.ID, &r.Title, &r.Subtitle, &r.Score); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// SearchFirms finds firms by name_1..3, ort and plz
+func (db *MySQLDB) SearchFirms(terms []string, limit int) ([]model.SearchResult, error) {
+	return db.searchMySQL(model.SearchTypeFirm, `
+	SELECT id,
+	       CONCAT_WS(' ', name_1, NULLIF(name_2, ''), NULLIF(name_3, '')),
+	       CONCAT_WS(' ', plz, ort),
+	       MATCH(name_1, name_2, name_3, ort, plz) AGAINST (? IN BOOLEAN MODE) AS score
+	FROM firms
+	WHERE MATCH(name_1, name_2, name_3, ort, plz) AGAINST (? IN BOOLEAN MODE) AND deleted_at IS NULL
+	ORDER BY score DESC
+	LIMIT ?`, booleanModeQuery(terms), limit)
+}
+
+// SearchContacts finds contacts by first name, last name and email
+func (db *MySQLDB) SearchContacts(terms []string, limit int) ([]model.SearchResult, error) {
+	return db.searchMySQL(model.SearchTypeContact, `
+	SELECT id,
+	       CONCAT_WS(' ', vorname, nachname),
+	       email,
+	       MATCH(vorname, nachname, email) AGAINST (? IN BOOLEAN MODE) AS score
+	FROM contacts
+	WHERE MATCH(vorname, nachname, email) AGAINST (? IN BOOLEAN MODE) AND deleted_at IS NULL
+	ORDER BY score DESC
+	LIMIT ?`, booleanModeQuery(terms), limit)
+}
+
+// SearchTickets finds tickets by title and description
+func (db *MySQLDB) SearchTickets(terms []string, limit int) ([]model.SearchResult, error) {
+	return db.searchMySQL(model.SearchTypeTicket, `
+	SELECT id,
+	       title,
+	       CONCAT(status, ' / ', priority),
+	       MATCH(title, description) AGAINST (? IN BOOLEAN MODE) AS score
+	FROM tickets
+	WHERE MATCH(title, description) AGAINST (? IN BOOLEAN MODE)
+	ORDER BY score DESC
+	LIMIT ?`, booleanModeQuery(terms), limit)
+}
+
+// deviceSearchDocument is the text indexed for device search. The GIN index
+// and the search query must use the identical expression for the index to apply.
+const deviceSearchDocument = `to_tsvector('simple',
+	coalesce(name, '') || ' ' || coalesce(hostname, '') || ' ' || coalesce(ip, '') || ' ' ||
+	coalesce(mac, '') || ' ' || coalesce(serial_numbers, ''))`
+
+// tsQueryQuote escapes a term for use inside a quoted tsquery lexeme, where
+// operators such as & | ! ( ) : * < > lose their meaning
+var tsQueryQuote = strings.NewReplacer(`\`, `\\`, `'`, `''`)
+
+// tsQuery turns search terms into a Postgres tsquery where every term is
+// required and matched as a prefix
+func tsQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = "'" + tsQueryQuote.Replace(term) + "':*"
+	}
+	return strings.Join(parts, " & ")
+}
+
+// SetupSearchIndex creates the full-text index used by SearchDevices
+func (p *PostgresDB) SetupSearchIndex() error {
+	_, err := p.DB.Exec(`CREATE INDEX IF NOT EXISTS idx_devices_search ON devices USING GIN (` + deviceSearchDocument + `)`)
+	if err != nil {
+		log.Error("Failed to create device search index: ", err)
+		return err
+	}
+	log.Info("Device search index setup completed")
+	return nil
+}
+
+// SearchDevices finds devices by name, hostname, IP, MAC and serial numbers
+func (p *PostgresDB) SearchDevices(terms []string, limit int) ([]model.SearchResult, error) {
+	rows, err := p.DB.Query(`
+	SELECT id, coalesce(name, ''), concat_ws(' ', nullif(hostname, ''), nullif(ip, '')),
+	       ts_rank(`+deviceSearchDocument+`, to_tsquery('simple', $1)) AS score
+	FROM devices
+	WHERE `+deviceSearchDocument+` @@ to_tsquery('simple', $1) AND deleted_at IS NULL
+	ORDER BY score DESC
+	LIMIT $2`, tsQuery(terms), limit)
+	if err != nil {
+		log.Errorf("Failed to search devices: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []model.SearchResult
+	for rows.Next() {
+		r := model.SearchResult{Type: model.SearchTypeDevice}
+		if err := rows.Scan(&r.ID, &r.Title, &r.Subtitle, &r.Score); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
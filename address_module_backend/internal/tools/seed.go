@@ -2,6 +2,8 @@ package tools
 
 import (
 	"address_module/internal/model"
+	"database/sql"
+	"errors"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -36,6 +38,22 @@ func (db *MySQLDB) SeedInitialData() error {
 		"unassign_roles":       "Unassign roles",
 		"assign_permissions":   "Assign permissions",
 		"unassign_permissions": "Unassign permissions",
+		"view_tickets":         "View tickets",
+		"edit_tickets":         "Edit tickets",
+		"create_tickets":       "Create tickets",
+		"delete_tickets":       "Delete tickets",
+		"manage_workflows":     "Create, edit and delete ticket workflows",
+		"view_internal_notes":  "View and write internal ticket notes",
+		"manage_sla":           "Create, edit, delete and assign SLA policies",
+		"manage_queues":        "Create, edit and delete support queues",
+		"view_deleted":         "View soft-deleted firms, contacts, users and devices",
+		"view_audit_log":       "View the audit log",
+		"revoke_sessions":      "Log users out of all sessions",
+		"view_devices":         "View devices and device links",
+		"create_devices":       "Create devices",
+		"edit_devices":         "Edit and revert devices",
+		"delete_devices":       "Delete and restore devices",
+		"manage_device_links":  "Create, edit and delete device links",
 		"admin_panel":          "Access admin panel",
 	}
 
@@ -50,6 +68,11 @@ func (db *MySQLDB) SeedInitialData() error {
 		}
 	}
 
+	if err := db.migrateDevicePermissions(); err != nil {
+		log.Errorf("❌ Failed to migrate device permissions: %v", err)
+		return err
+	}
+
 	adminRoleName := "admin"
 	adminRole, err := db.GetRoleByName(adminRoleName)
 	if err != nil {
@@ -71,21 +94,26 @@ func (db *MySQLDB) SeedInitialData() error {
 		log.Errorf("❌ Failed to create hashed admin password: %v", err)
 		return err
 	}
-	adminUser, err := db.GetUserByEmail(adminEmail)
-	if err != nil {
-		adminUser = &model.User{
+	// The admin is only created on a fresh install. If an operator soft-deleted
+	// it, it stays deleted; the row still exists, so nothing is inserted.
+	var adminID int64
+	var adminDeleted bool
+	err = db.DB.QueryRow(`SELECT id, deleted_at IS NOT NULL FROM users WHERE email = ?`, adminEmail).Scan(&adminID, &adminDeleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		adminID, err = db.InsertUser(model.User{
 			Username:       "admin",
 			Email:          adminEmail,
 			HashedPassword: string(hashedPassword),
 			CreatedAt:      time.Now(),
-		}
-		uid, err := db.InsertUser(*adminUser)
+		})
 		if err != nil {
 			log.Errorf("❌ Failed to insert admin user: %v", err)
 			return err
 		}
-		log.Infof("✅ Created admin user with ID %d", uid)
-		adminUser.ID = uid
+		log.Infof("✅ Created admin user with ID %d", adminID)
+	} else if err != nil {
+		log.Errorf("❌ Failed to look up admin user: %v", err)
+		return err
 	}
 
 	for name := range permissionNames {
@@ -103,14 +131,60 @@ func (db *MySQLDB) SeedInitialData() error {
 		}
 	}
 
-	err = db.InsertUserRole(model.UserRole{
-		UserID: adminUser.ID,
-		RoleID: adminRole.ID,
-	})
-	if err != nil {
-		log.Warnf("🔁 Admin role already assigned to admin user or failed: %v", err)
+	if adminDeleted {
+		log.Info("Default admin user is deleted, leaving it disabled")
+	} else {
+		// The seeded admin has to be able to log in before any mail is delivered
+		if err := db.MarkEmailVerified(adminID, time.Now()); err != nil {
+			log.Warnf("Could not mark admin email verified: %v", err)
+		}
+
+		err = db.InsertUserRole(model.UserRole{
+			UserID: adminID,
+			RoleID: adminRole.ID,
+		})
+		if err != nil {
+			log.Warnf("🔁 Admin role already assigned to admin user or failed: %v", err)
+		}
+	}
+
+	if err := db.seedDefaultWorkflow(); err != nil {
+		log.Errorf("❌ Failed to seed default workflow: %v", err)
+		return err
 	}
 
 	log.Info("🎉 Seeding complete.")
 	return nil
 }
+
+// seedDefaultWorkflow creates the built-in ticket workflow if no default exists yet
+func (db *MySQLDB) seedDefaultWorkflow() error {
+	if _, err := db.GetWorkflowByName("default"); err == nil {
+		return nil
+	}
+
+	wf := model.Workflow{
+		Name:          "default",
+		Description:   "Standard support workflow",
+		InitialStatus: model.TicketStatusNew,
+		IsDefault:     true,
+		Transitions: []model.WorkflowTransition{
+			{FromStatus: "new", ToStatus: "triaged", Name: "Triage"},
+			{FromStatus: "triaged", ToStatus: "in_progress", Name: "Start work"},
+			{FromStatus: "in_progress", ToStatus: "waiting_on_customer", Name: "Wait for customer"},
+			{FromStatus: "waiting_on_customer", ToStatus: "in_progress", Name: "Customer replied"},
+			{FromStatus: "in_progress", ToStatus: "resolved", Name: "Resolve"},
+			{FromStatus: "resolved", ToStatus: "closed", Name: "Close"},
+			{FromStatus: "resolved", ToStatus: "in_progress", Name: "Reopen"},
+			{FromStatus: "closed", ToStatus: "in_progress", Name: "Reopen"},
+		},
+		ClosedStatuses: []string{"resolved", "closed"},
+	}
+
+	id, err := db.InsertWorkflow(wf)
+	if err != nil {
+		return err
+	}
+	log.Infof("✅ Created default workflow with ID %d", id)
+	return nil
+}
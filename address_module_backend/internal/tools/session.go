@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"address_module/internal/model"
+	"database/sql"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrRefreshTokenInvalid is returned for unknown or expired refresh tokens
+	ErrRefreshTokenInvalid = errors.New("refresh token invalid or expired")
+	// ErrRefreshTokenReused is returned when an already rotated or revoked
+	// refresh token is presented again; the whole session is revoked
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+)
+
+// InsertRefreshToken stores the refresh token issued at login
+func (db *MySQLDB) InsertRefreshToken(t model.RefreshToken) (int64, error) {
+	return insertRefreshToken(db.DB, t)
+}
+
+func insertRefreshToken(exec interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}, t model.RefreshToken) (int64, error) {
+	res, err := exec.Exec(`
+	INSERT INTO refresh_tokens (user_id, family_id, token_hash, access_jti, access_expires_at, expires_at)
+	VALUES (?, ?, ?, ?, ?, ?)`,
+		t.UserID, t.FamilyID, t.TokenHash, t.AccessJTI, t.AccessExpiresAt, t.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// RotateRefreshToken exchanges the refresh token with hash tokenHash for next.
+// next only needs the new hash, access jti and expiry times; the user and
+// family are copied from the presented token and the filled-in row is
+// returned. Presenting a token that was already rotated or revoked revokes
+// the whole family and returns ErrRefreshTokenReused.
+func (db *MySQLDB) RotateRefreshToken(tokenHash string, next model.RefreshToken, now time.Time) (*model.RefreshToken, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var current model.RefreshToken
+	err = tx.QueryRow(`
+	SELECT id, user_id, family_id, expires_at, used_at, revoked_at
+	FROM refresh_tokens WHERE token_hash = ? FOR UPDATE`, tokenHash).
+		Scan(&current.ID, &current.UserID, &current.FamilyID, &current.ExpiresAt, &current.UsedAt, &current.RevokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRefreshTokenInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if current.UsedAt != nil || current.RevokedAt != nil {
+		if err := revokeFamily(tx, current.FamilyID, now); err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		log.WithFields(log.Fields{"user_id": current.UserID, "family_id": current.FamilyID}).
+			Warn("Refresh token reuse detected, session revoked")
+		return nil, ErrRefreshTokenReused
+	}
+	if !now.Before(current.ExpiresAt) {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	next.UserID = current.UserID
+	next.FamilyID = current.FamilyID
+	nextID, err := insertRefreshToken(tx, next)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET used_at = ?, replaced_by = ? WHERE id = ?`, now, nextID, current.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	next.ID = nextID
+	return &next, nil
+}
+
+// revokeFamily revokes every refresh token of a session together with the
+// access tokens issued alongside them that have not expired yet
+func revokeFamily(tx *sql.Tx, familyID string, now time.Time) error {
+	_, err := tx.Exec(`
+	INSERT IGNORE INTO revoked_tokens (jti, user_id, expires_at)
+	SELECT access_jti, user_id, access_expires_at FROM refresh_tokens
+	WHERE family_id = ? AND access_expires_at > ?`, familyID, now)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL`, now, familyID)
+	return err
+}
+
+// RevokeSession revokes the session an access token belongs to (logout)
+func (db *MySQLDB) RevokeSession(familyID string, now time.Time) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := revokeFamily(tx, familyID, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RevokeUserSessions revokes every session of a user and returns how many
+// sessions were still active
+func (db *MySQLDB) RevokeUserSessions(userID int64, now time.Time) (int64, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var active int64
+	err = tx.QueryRow(`
+	SELECT COUNT(DISTINCT family_id) FROM refresh_tokens
+	WHERE user_id = ? AND revoked_at IS NULL AND used_at IS NULL AND expires_at > ?`, userID, now).Scan(&active)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(`
+	INSERT IGNORE INTO revoked_tokens (jti, user_id, expires_at)
+	SELECT access_jti, user_id, access_expires_at FROM refresh_tokens
+	WHERE user_id = ? AND access_expires_at > ?`, userID, now)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL`, now, userID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return active, nil
+}
+
+// RevokeAccessToken adds a single access token to the revocation list
+func (db *MySQLDB) RevokeAccessToken(jti string, userID int64, expiresAt time.Time) error {
+	_, err := db.DB.Exec(`INSERT IGNORE INTO revoked_tokens (jti, user_id, expires_at) VALUES (?, ?, ?)`, jti, userID, expiresAt)
+	return err
+}
+
+// IsTokenRevoked reports whether the access token with the given jti was revoked
+func (db *MySQLDB) IsTokenRevoked(jti string) (bool, error) {
+	var exists int
+	err := db.DB.QueryRow(`SELECT COUNT(*) FROM revoked_tokens WHERE jti = ?`, jti).Scan(&exists)
+	return exists > 0, err
+}
+
+// PurgeExpiredTokens drops refresh tokens, revocation entries and reset or
+// verification tokens that can no longer be used. Rotated refresh tokens are
+// kept until they expire so reuse is still detected.
+func (db *MySQLDB) PurgeExpiredTokens(now time.Time) (int64, error) {
+	var total int64
+	for _, query := range []string{
+		`DELETE FROM refresh_tokens WHERE expires_at < ?`,
+		`DELETE FROM revoked_tokens WHERE expires_at < ?`,
+		`DELETE FROM user_tokens WHERE expires_at < ?`,
+	} {
+		res, err := db.DB.Exec(query, now)
+		if err != nil {
+			return total, err
+		}
+		n, _ := res.RowsAffected()
+		total += n
+	}
+	if total > 0 {
+		log.Infof("Purged %d expired tokens", total)
+	}
+	return total, nil
+}
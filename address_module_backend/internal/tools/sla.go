@@ -0,0 +1,386 @@
+package tools
+
+import (
+	"address_module/internal/model"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func formatWorkDays(days []int) string {
+	parts := make([]string, len(days))
+	for i, d := range days {
+		parts[i] = strconv.Itoa(d)
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseWorkDays(s string) []int {
+	days := []int{}
+	for _, p := range strings.Split(s, ",") {
+		if d, err := strconv.Atoi(strings.TrimSpace(p)); err == nil {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// openTicketFilter is a condition on the tickets row named alias that holds
+// while the ticket is not in one of its workflow's closed statuses. Tickets
+// without a workflow follow the default workflow.
+func openTicketFilter(alias string) string {
+	return `NOT EXISTS (
+		SELECT 1 FROM workflow_closed_statuses wcs
+		WHERE wcs.status = ` + alias + `.status
+		  AND wcs.workflow_id = COALESCE(` + alias + `.workflow_id,
+		      (SELECT id FROM workflows WHERE is_default = TRUE ORDER BY id LIMIT 1)))`
+}
+
+func loadSLAPolicyDetails(q queryer, p *model.SLAPolicy) error {
+	rows, err := q.Query(`
+	SELECT priority, first_response_minutes, resolution_minutes
+	FROM sla_targets
+	WHERE policy_id = ?
+	ORDER BY FIELD(priority, 'critical', 'high', 'normal', 'low')`, p.ID)
+	if err != nil {
+		return err
+	}
+	p.Targets = []model.SLATarget{}
+	for rows.Next() {
+		var t model.SLATarget
+		if err := rows.Scan(&t.Priority, &t.FirstResponseMinutes, &t.ResolutionMinutes); err != nil {
+			rows.Close()
+			return err
+		}
+		p.Targets = append(p.Targets, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = q.Query(`
+	SELECT DATE_FORMAT(holiday, '%Y-%m-%d'), COALESCE(description, '')
+	FROM sla_holidays
+	WHERE policy_id = ?
+	ORDER BY holiday`, p.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	p.Holidays = []model.SLAHoliday{}
+	for rows.Next() {
+		var h model.SLAHoliday
+		if err := rows.Scan(&h.Date, &h.Description); err != nil {
+			return err
+		}
+		p.Holidays = append(p.Holidays, h)
+	}
+	return rows.Err()
+}
+
+func loadSLAPolicy(q queryer, where string, args ...interface{}) (*model.SLAPolicy, error) {
+	var p model.SLAPolicy
+	var workDays string
+	err := q.QueryRow(`
+	SELECT p.id, p.name, COALESCE(p.description, ''), p.timezone, p.business_hours_start, p.business_hours_end, p.work_days
+	FROM sla_policies p `+where, args...).Scan(
+		&p.ID, &p.Name, &p.Description, &p.Timezone, &p.BusinessHoursStart, &p.BusinessHoursEnd, &workDays)
+	if err != nil {
+		return nil, err
+	}
+	p.WorkDays = parseWorkDays(workDays)
+	if err := loadSLAPolicyDetails(q, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func insertSLAPolicyDetails(tx *sql.Tx, policyID int64, p model.SLAPolicy) error {
+	for _, t := range p.Targets {
+		_, err := tx.Exec(`
+		INSERT INTO sla_targets (policy_id, priority, first_response_minutes, resolution_minutes)
+		VALUES (?, ?, ?, ?)`, policyID, t.Priority, t.FirstResponseMinutes, t.ResolutionMinutes)
+		if err != nil {
+			return err
+		}
+	}
+	for _, h := range p.Holidays {
+		_, err := tx.Exec(`
+		INSERT INTO sla_holidays (policy_id, holiday, description)
+		VALUES (?, ?, ?)`, policyID, h.Date, h.Description)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InsertSLAPolicy inserts a policy with its targets and holidays and returns its ID
+func (db *MySQLDB) InsertSLAPolicy(p model.SLAPolicy) (int64, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return 0, err
+	}
+
+	result, err := tx.Exec(`
+	INSERT INTO sla_policies (name, description, timezone, business_hours_start, business_hours_end, work_days)
+	VALUES (?, ?, ?, ?, ?, ?)`,
+		p.Name, p.Description, p.Timezone, p.BusinessHoursStart, p.BusinessHoursEnd, formatWorkDays(p.WorkDays))
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to insert SLA policy: ", err)
+		return 0, err
+	}
+
+	policyID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to get SLA policy ID: ", err)
+		return 0, err
+	}
+
+	if err := insertSLAPolicyDetails(tx, policyID, p); err != nil {
+		tx.Rollback()
+		log.Error("Failed to insert SLA targets or holidays: ", err)
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return 0, err
+	}
+
+	log.Info("SLA policy inserted successfully with ID: ", policyID)
+	return policyID, nil
+}
+
+// GetSLAPolicyByID fetches a policy with its targets and holidays
+func (db *MySQLDB) GetSLAPolicyByID(id int64) (*model.SLAPolicy, error) {
+	p, err := loadSLAPolicy(db.DB, `WHERE p.id = ?`, id)
+	if err != nil {
+		log.Error("Failed to get SLA policy: ", err)
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetSLAPolicyForFirm returns the policy assigned to a firm, or nil if it has none
+func (db *MySQLDB) GetSLAPolicyForFirm(firmID int64) (*model.SLAPolicy, error) {
+	p, err := loadSLAPolicy(db.DB, `JOIN firms f ON f.sla_policy_id = p.id WHERE f.id = ?`, firmID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Error("Failed to get SLA policy for firm: ", err)
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetAllSLAPolicies retrieves all policies with their targets and holidays
+func (db *MySQLDB) GetAllSLAPolicies() ([]model.SLAPolicy, error) {
+	rows, err := db.DB.Query(`SELECT id FROM sla_policies ORDER BY id`)
+	if err != nil {
+		log.Error("Failed to query SLA policies: ", err)
+		return nil, err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	policies := make([]model.SLAPolicy, 0, len(ids))
+	for _, id := range ids {
+		p, err := db.GetSLAPolicyByID(id)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *p)
+	}
+	return policies, nil
+}
+
+// UpdateSLAPolicy replaces a policy definition including its targets and holidays.
+// Due dates of existing tickets are not recomputed.
+func (db *MySQLDB) UpdateSLAPolicy(p model.SLAPolicy) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return err
+	}
+
+	var exists int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM sla_policies WHERE id = ?`, p.ID).Scan(&exists); err != nil || exists == 0 {
+		tx.Rollback()
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.Exec(`
+	UPDATE sla_policies
+	SET name = ?, description = ?, timezone = ?, business_hours_start = ?, business_hours_end = ?, work_days = ?
+	WHERE id = ?`,
+		p.Name, p.Description, p.Timezone, p.BusinessHoursStart, p.BusinessHoursEnd, formatWorkDays(p.WorkDays), p.ID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to update SLA policy: ", err)
+		return err
+	}
+
+	for _, table := range []string{"sla_targets", "sla_holidays"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE policy_id = ?`, p.ID); err != nil {
+			tx.Rollback()
+			log.Error("Failed to clear ", table, ": ", err)
+			return err
+		}
+	}
+	if err := insertSLAPolicyDetails(tx, p.ID, p); err != nil {
+		tx.Rollback()
+		log.Error("Failed to insert SLA targets or holidays: ", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	log.Info("SLA policy updated successfully")
+	return nil
+}
+
+// DeleteSLAPolicy deletes a policy; firms using it are left without an SLA
+func (db *MySQLDB) DeleteSLAPolicy(id int64) error {
+	result, err := db.DB.Exec(`DELETE FROM sla_policies WHERE id = ?`, id)
+	if err != nil {
+		log.Error("Failed to delete SLA policy: ", err)
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	log.Info("SLA policy deleted successfully")
+	return nil
+}
+
+// AssignSLAPolicyToFirm sets or, with a nil policy, clears the SLA policy of a firm
+func (db *MySQLDB) AssignSLAPolicyToFirm(firmID int64, policyID *int64) error {
+	result, err := db.DB.Exec(`UPDATE firms SET sla_policy_id = ? WHERE id = ?`, policyID, firmID)
+	if err != nil {
+		log.Error("Failed to assign SLA policy: ", err)
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		var exists int
+		if err := db.DB.QueryRow(`SELECT COUNT(*) FROM firms WHERE id = ?`, firmID).Scan(&exists); err != nil || exists == 0 {
+			return sql.ErrNoRows
+		}
+	}
+	return nil
+}
+
+// ComputeTicketDueDates returns the first-response and resolution deadlines for a
+// ticket of the given firm and priority, counted in business minutes from `from`.
+// Both are nil when the firm has no policy or the policy has no target for the priority.
+func (db *MySQLDB) ComputeTicketDueDates(firmID *int64, priority string, from time.Time) (*time.Time, *time.Time, error) {
+	if firmID == nil {
+		return nil, nil, nil
+	}
+	policy, err := db.GetSLAPolicyForFirm(*firmID)
+	if err != nil || policy == nil {
+		return nil, nil, err
+	}
+	target, ok := policy.Target(priority)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	cal, err := NewBusinessCalendar(policy)
+	if err != nil {
+		log.Error("Invalid business calendar for SLA policy ", policy.ID, ": ", err)
+		return nil, nil, err
+	}
+	response := cal.AddBusinessMinutes(from, target.FirstResponseMinutes).UTC()
+	resolution := cal.AddBusinessMinutes(from, target.ResolutionMinutes).UTC()
+	return &response, &resolution, nil
+}
+
+const ticketDueDatesQuery = `
+	UPDATE tickets SET first_response_due_at = ?, resolution_due_at = ?, escalated_at = NULL
+	WHERE id = ?`
+
+// SetTicketDueDates overwrites the SLA deadlines of a ticket. New deadlines
+// also reset the escalation so the scheduler can escalate against them again.
+func (db *MySQLDB) SetTicketDueDates(ticketID int64, firstResponse, resolution *time.Time) error {
+	_, err := db.DB.Exec(ticketDueDatesQuery, firstResponse, resolution, ticketID)
+	if err != nil {
+		log.Error("Failed to set ticket due dates: ", err)
+		return err
+	}
+	return nil
+}
+
+// MarkFirstResponse records the first public reply on a ticket; later replies are ignored
+func (db *MySQLDB) MarkFirstResponse(ticketID int64, at time.Time) error {
+	_, err := db.DB.Exec(`
+	UPDATE tickets SET first_responded_at = ?
+	WHERE id = ? AND first_responded_at IS NULL`, at.UTC(), ticketID)
+	if err != nil {
+		log.Error("Failed to mark first response: ", err)
+		return err
+	}
+	return nil
+}
+
+// GetBreachingTickets returns open tickets whose first-response or resolution
+// deadline lies before `until`. A ticket about to breach both is listed once per deadline.
+func (db *MySQLDB) GetBreachingTickets(now, until time.Time) ([]model.SLABreach, error) {
+	rows, err := db.DB.Query(`
+	SELECT `+ticketColumns+`
+	FROM tickets
+	WHERE ((first_responded_at IS NULL AND first_response_due_at < ?) OR resolution_due_at < ?)
+	  AND `+openTicketFilter("tickets")+`
+	ORDER BY LEAST(COALESCE(first_response_due_at, resolution_due_at), COALESCE(resolution_due_at, first_response_due_at))`,
+		until.UTC(), until.UTC())
+	if err != nil {
+		log.Error("Failed to query breaching tickets: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	breaches := []model.SLABreach{}
+	for rows.Next() {
+		ticket, err := scanTicket(rows)
+		if err != nil {
+			log.Error("Failed to scan ticket row: ", err)
+			return nil, err
+		}
+		if ticket.FirstRespondedAt == nil && ticket.FirstResponseDueAt != nil && ticket.FirstResponseDueAt.Before(until) {
+			breaches = append(breaches, model.SLABreach{
+				Ticket: *ticket, Breach: "first_response",
+				DueAt: *ticket.FirstResponseDueAt, Overdue: ticket.FirstResponseDueAt.Before(now),
+			})
+		}
+		if ticket.ResolutionDueAt != nil && ticket.ResolutionDueAt.Before(until) {
+			breaches = append(breaches, model.SLABreach{
+				Ticket: *ticket, Breach: "resolution",
+				DueAt: *ticket.ResolutionDueAt, Overdue: ticket.ResolutionDueAt.Before(now),
+			})
+		}
+	}
+	return breaches, rows.Err()
+}
@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"database/sql"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Firms, contacts, users (MySQL) and devices (Postgres) are deleted softly:
+// deleted_at/deleted_by are set and the row disappears from lookups and lists.
+// Restoring clears both columns; PurgeDeleted removes rows for good once the
+// retention period has passed.
+
+// notDeleted returns the list scope hiding soft-deleted rows, or "" to show them
+func notDeleted(includeDeleted bool, column string) string {
+	if includeDeleted {
+		return ""
+	}
+	return column + " IS NULL"
+}
+
+// softDelete marks a row as deleted; sql.ErrNoRows if it does not exist or is already deleted
+func (db *MySQLDB) softDelete(table string, id int64, deletedBy *int64) error {
+	result, err := db.DB.Exec(`UPDATE `+table+` SET deleted_at = ?, deleted_by = ? WHERE id = ? AND deleted_at IS NULL`,
+		time.Now(), deletedBy, id)
+	if err != nil {
+		log.Errorf("Failed to soft-delete %s row: %v", table, err)
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	log.WithFields(log.Fields{"table": table, "id": id, "deleted_by": deletedBy}).Info("Row soft-deleted")
+	return nil
+}
+
+// restore brings back a soft-deleted row; sql.ErrNoRows if it is not deleted
+func (db *MySQLDB) restore(table string, id int64) error {
+	result, err := db.DB.Exec(`UPDATE `+table+` SET deleted_at = NULL, deleted_by = NULL WHERE id = ? AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		log.Errorf("Failed to restore %s row: %v", table, err)
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	log.WithFields(log.Fields{"table": table, "id": id}).Info("Row restored")
+	return nil
+}
+
+// SoftDeleteFirm hides a firm from lists and lookups but keeps the row, so
+// tickets and contact relations still point to it
+func (db *MySQLDB) SoftDeleteFirm(id int64, deletedBy *int64) error {
+	return db.softDelete("firms", id, deletedBy)
+}
+
+// RestoreFirm undoes SoftDeleteFirm
+func (db *MySQLDB) RestoreFirm(id int64) error {
+	return db.restore("firms", id)
+}
+
+// SoftDeleteContact hides a contact; its firm relations stay for a restore
+func (db *MySQLDB) SoftDeleteContact(id int64, deletedBy *int64) error {
+	return db.softDelete("contacts", id, deletedBy)
+}
+
+// RestoreContact undoes SoftDeleteContact
+func (db *MySQLDB) RestoreContact(id int64) error {
+	return db.restore("contacts", id)
+}
+
+// SoftDeleteUser hides a user; the user can no longer log in and loses all permissions
+func (db *MySQLDB) SoftDeleteUser(id int64, deletedBy *int64) error {
+	return db.softDelete("users", id, deletedBy)
+}
+
+// RestoreUser undoes SoftDeleteUser
+func (db *MySQLDB) RestoreUser(id int64) error {
+	return db.restore("users", id)
+}
+
+// PurgeDeleted permanently removes firms, contacts and users that were
+// soft-deleted before the given time. Firms that still have open tickets are
+// kept. It returns the number of rows removed.
+func (db *MySQLDB) PurgeDeleted(before time.Time) (int64, error) {
+	purges := []struct {
+		table string
+		query string
+		args  []interface{}
+	}{
+		{"firms", `DELETE FROM firms WHERE deleted_at < ? AND NOT EXISTS (
+			SELECT 1 FROM tickets t WHERE t.firma_id = firms.id AND ` + openTicketFilter("t") + `)`,
+			[]interface{}{before}},
+		{"contacts", `DELETE FROM contacts WHERE deleted_at < ?`, []interface{}{before}},
+		{"users", `DELETE FROM users WHERE deleted_at < ?`, []interface{}{before}},
+	}
+
+	var total int64
+	for _, p := range purges {
+		result, err := db.DB.Exec(p.query, p.args...)
+		if err != nil {
+			log.Errorf("Failed to purge deleted %s: %v", p.table, err)
+			return total, err
+		}
+		n, _ := result.RowsAffected()
+		if n > 0 {
+			log.WithFields(log.Fields{"table": p.table, "rows": n}).Info("Purged soft-deleted rows")
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// SoftDeleteDevice hides a device; its links stay for a restore
+func (p *PostgresDB) SoftDeleteDevice(id int64, deletedBy *int64) error {
+	result, err := p.DB.Exec(`UPDATE devices SET deleted_at = $1, deleted_by = $2 WHERE id = $3 AND deleted_at IS NULL`,
+		time.Now(), deletedBy, id)
+	if err != nil {
+		log.Errorf("Failed to soft-delete device: %v", err)
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	log.WithFields(log.Fields{"device_id": id, "deleted_by": deletedBy}).Info("Device soft-deleted")
+	return nil
+}
+
+// RestoreDevice undoes SoftDeleteDevice
+func (p *PostgresDB) RestoreDevice(id int64) error {
+	result, err := p.DB.Exec(`UPDATE devices SET deleted_at = NULL, deleted_by = NULL WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		log.Errorf("Failed to restore device: %v", err)
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	log.WithField("device_id", id).Info("Device restored")
+	return nil
+}
+
+// PurgeDeleted permanently removes devices soft-deleted before the given
+// time; their links go with them (ON DELETE CASCADE)
+func (p *PostgresDB) PurgeDeleted(before time.Time) (int64, error) {
+	result, err := p.DB.Exec(`DELETE FROM devices WHERE deleted_at < $1`, before)
+	if err != nil {
+		log.Errorf("Failed to purge deleted devices: %v", err)
+		return 0, err
+	}
+	n, _ := result.RowsAffected()
+	if n > 0 {
+		log.WithFields(log.Fields{"table": "devices", "rows": n}).Info("Purged soft-deleted rows")
+	}
+	return n, nil
+}
@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"address_module/internal/model"
+	"database/sql"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const ticketColumns = `id, title, description, status, priority, contact_id, assignee_id, firma_id, workflow_id, created_by, created_at, updated_at,
+	first_response_due_at, resolution_due_at, first_responded_at, escalated_at, queue_id`
+
+const ticketInsertQuery = `
+	INSERT INTO tickets (title, description, status, priority, contact_id, assignee_id, firma_id, workflow_id, created_by,
+	                     first_response_due_at, resolution_due_at, queue_id)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+// ticketInsertArgs returns the arguments for ticketInsertQuery
+func ticketInsertArgs(t model.Ticket) []interface{} {
+	return []interface{}{t.Title, t.Description, t.Status, t.Priority,
+		t.ContactID, t.AssigneeID, t.FirmaID, t.WorkflowID, t.CreatedBy,
+		t.FirstResponseDueAt, t.ResolutionDueAt, t.QueueID}
+}
+
+const ticketUpdateQuery = `
+	UPDATE tickets
+	SET title = ?, description = ?, priority = ?, contact_id = ?, assignee_id = ?, firma_id = ?
+	WHERE id = ?`
+
+// ticketUpdateArgs returns the arguments for ticketUpdateQuery
+func ticketUpdateArgs(t model.Ticket) []interface{} {
+	return []interface{}{t.Title, t.Description, t.Priority,
+		t.ContactID, t.AssigneeID, t.FirmaID, t.ID}
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// prefixColumns qualifies a comma-separated column list with a table alias
+func prefixColumns(alias, columns string) string {
+	parts := strings.Split(columns, ",")
+	for i, p := range parts {
+		parts[i] = alias + "." + strings.TrimSpace(p)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func scanTicket(s scanner) (*model.Ticket, error) {
+	var t model.Ticket
+	err := s.Scan(
+		&t.ID, &t.Title, &t.Description, &t.Status, &t.Priority,
+		&t.ContactID, &t.AssigneeID, &t.FirmaID, &t.WorkflowID, &t.CreatedBy,
+		&t.CreatedAt, &t.UpdatedAt,
+		&t.FirstResponseDueAt, &t.ResolutionDueAt, &t.FirstRespondedAt, &t.EscalatedAt, &t.QueueID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// InsertTicket inserts a ticket and returns its ID
+func (db *MySQLDB) InsertTicket(ticket model.Ticket) (int64, error) {
+	result, err := db.DB.Exec(ticketInsertQuery, ticketInsertArgs(ticket)...)
+	if err != nil {
+		log.Error("Failed to insert ticket: ", err)
+		return 0, err
+	}
+
+	ticketID, err := result.LastInsertId()
+	if err != nil {
+		log.Error("Failed to get ticket ID: ", err)
+		return 0, err
+	}
+
+	log.Info("Ticket inserted successfully with ID: ", ticketID)
+	return ticketID, nil
+}
+
+// GetTicketByID fetches a ticket by ID
+func (db *MySQLDB) GetTicketByID(id int64) (*model.Ticket, error) {
+	query := `SELECT ` + ticketColumns + ` FROM tickets WHERE id = ?`
+
+	ticket, err := scanTicket(db.DB.QueryRow(query, id))
+	if err != nil {
+		log.Error("Failed to get ticket: ", err)
+		return nil, err
+	}
+	return ticket, nil
+}
+
+// GetAllTickets retrieves all tickets, newest first
+func (db *MySQLDB) GetAllTickets() ([]model.Ticket, error) {
+	return db.getTicketsWhere(``)
+}
+
+// UpdateTicket updates the editable fields of a ticket.
+// The status is left untouched; it only changes through TransitionTicket.
+func (db *MySQLDB) UpdateTicket(ticket model.Ticket) error {
+	_, err := db.DB.Exec(ticketUpdateQuery, ticketUpdateArgs(ticket)...)
+	if err != nil {
+		log.Error("Failed to update ticket: ", err)
+		return err
+	}
+
+	log.Info("Ticket updated successfully")
+	return nil
+}
+
+// DeleteTicket deletes a ticket by ID. It returns sql.ErrNoRows if no ticket has that ID.
+func (db *MySQLDB) DeleteTicket(id int64) error {
+	query := `DELETE FROM tickets WHERE id = ?`
+
+	result, err := db.DB.Exec(query, id)
+	if err != nil {
+		log.Error("Failed to delete ticket: ", err)
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+
+	log.Info("Ticket deleted successfully")
+	return nil
+}
+
+// getTicketsWhere returns the tickets matching a WHERE clause, newest first
+func (db *MySQLDB) getTicketsWhere(where string, args ...interface{}) ([]model.Ticket, error) {
+	query := `SELECT ` + ticketColumns + ` FROM tickets ` + where + ` ORDER BY id DESC`
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		log.Error("Failed to query tickets: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tickets := []model.Ticket{}
+	for rows.Next() {
+		ticket, err := scanTicket(rows)
+		if err != nil {
+			log.Error("Failed to scan ticket row: ", err)
+			return nil, err
+		}
+		tickets = append(tickets, *ticket)
+	}
+	return tickets, rows.Err()
+}
+
+// GetTicketsByFirmID returns all tickets of a firm
+func (db *MySQLDB) GetTicketsByFirmID(firmID int64) ([]model.Ticket, error) {
+	return db.getTicketsWhere(`WHERE firma_id = ?`, firmID)
+}
+
+// GetTicketsByContactID returns all tickets requested by a contact
+func (db *MySQLDB) GetTicketsByContactID(contactID int64) ([]model.Ticket, error) {
+	return db.getTicketsWhere(`WHERE contact_id = ?`, contactID)
+}
+
+// GetContactIDByEmail looks up a contact through the unique idx_contact_email key
+func (db *MySQLDB) GetContactIDByEmail(email string) (int64, error) {
+	var id int64
+	err := db.DB.QueryRow(`SELECT id FROM contacts WHERE email = ? AND deleted_at IS NULL`, strings.TrimSpace(email)).Scan(&id)
+	return id, err
+}
+
+// GetPrimaryFirmForContact returns the firm a contact belongs to, preferring the
+// firm where the contact is hauptansprechpartner. Deleted firms are skipped; it
+// returns nil if the contact has no remaining firm.
+func (db *MySQLDB) GetPrimaryFirmForContact(contactID int64) (*int64, error) {
+	var firmID int64
+	err := db.DB.QueryRow(`
+	SELECT fc.firma_id FROM firms_contacts fc
+	JOIN firms f ON f.id = fc.firma_id
+	WHERE fc.contact_id = ? AND f.deleted_at IS NULL
+	ORDER BY fc.hauptansprechpartner DESC, fc.id ASC
+	LIMIT 1`, contactID).Scan(&firmID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Error("Failed to get firm for contact: ", err)
+		return nil, err
+	}
+	return &firmID, nil
+}
@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"address_module/internal/model"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const ticketAttachmentColumns = `a.id, a.ticket_id, a.comment_id, a.filename, a.content_type, a.size, a.sha256,
+	a.uploaded_by, COALESCE(c.internal, FALSE), a.created_at`
+
+func scanTicketAttachment(s scanner) (*model.TicketAttachment, error) {
+	var a model.TicketAttachment
+	err := s.Scan(&a.ID, &a.TicketID, &a.CommentID, &a.Filename, &a.ContentType, &a.Size, &a.SHA256,
+		&a.UploadedBy, &a.Internal, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// InsertTicketAttachment records an uploaded file and returns its ID
+func (db *MySQLDB) InsertTicketAttachment(a model.TicketAttachment) (int64, error) {
+	result, err := db.DB.Exec(`
+	INSERT INTO ticket_attachments (ticket_id, comment_id, filename, content_type, size, sha256, uploaded_by)
+	VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		a.TicketID, a.CommentID, a.Filename, a.ContentType, a.Size, a.SHA256, a.UploadedBy)
+	if err != nil {
+		log.Error("Failed to insert ticket attachment: ", err)
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetTicketAttachmentByID fetches an attachment including whether it belongs to an internal note
+func (db *MySQLDB) GetTicketAttachmentByID(id int64) (*model.TicketAttachment, error) {
+	row := db.DB.QueryRow(`
+	SELECT `+ticketAttachmentColumns+`
+	FROM ticket_attachments a
+	LEFT JOIN ticket_comments c ON c.id = a.comment_id
+	WHERE a.id = ?`, id)
+	return scanTicketAttachment(row)
+}
+
+// GetTicketAttachments lists the attachments of a ticket and its comments.
+// Attachments of internal notes are only included when includeInternal is set.
+func (db *MySQLDB) GetTicketAttachments(ticketID int64, includeInternal bool) ([]model.TicketAttachment, error) {
+	rows, err := db.DB.Query(`
+	SELECT `+ticketAttachmentColumns+`
+	FROM ticket_attachments a
+	LEFT JOIN ticket_comments c ON c.id = a.comment_id
+	WHERE a.ticket_id = ? AND (COALESCE(c.internal, FALSE) = FALSE OR ?)
+	ORDER BY a.created_at, a.id`, ticketID, includeInternal)
+	if err != nil {
+		log.Error("Failed to query ticket attachments: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []model.TicketAttachment{}
+	for rows.Next() {
+		a, err := scanTicketAttachment(rows)
+		if err != nil {
+			log.Error("Failed to scan ticket attachment row: ", err)
+			return nil, err
+		}
+		attachments = append(attachments, *a)
+	}
+	return attachments, rows.Err()
+}
@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"address_module/internal/model"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// InsertTicketComment adds a comment to a ticket and returns it as stored
+func (db *MySQLDB) InsertTicketComment(comment model.TicketComment) (*model.TicketComment, error) {
+	query := `
+	INSERT INTO ticket_comments (ticket_id, author_id, author_contact_id, body, internal)
+	VALUES (?, ?, ?, ?, ?)`
+
+	result, err := db.DB.Exec(query, comment.TicketID, comment.AuthorID, comment.AuthorContactID, comment.Body, comment.Internal)
+	if err != nil {
+		log.Error("Failed to insert ticket comment: ", err)
+		return nil, err
+	}
+
+	commentID, err := result.LastInsertId()
+	if err != nil {
+		log.Error("Failed to get ticket comment ID: ", err)
+		return nil, err
+	}
+
+	var stored model.TicketComment
+	err = db.DB.QueryRow(`
+	SELECT id, ticket_id, author_id, author_contact_id, body, internal, created_at
+	FROM ticket_comments WHERE id = ?`, commentID).Scan(
+		&stored.ID, &stored.TicketID, &stored.AuthorID, &stored.AuthorContactID, &stored.Body, &stored.Internal, &stored.CreatedAt,
+	)
+	if err != nil {
+		log.Error("Failed to reload ticket comment: ", err)
+		return nil, err
+	}
+
+	log.Info("Ticket comment inserted successfully with ID: ", commentID)
+	return &stored, nil
+}
+
+// GetTicketComments returns the conversation of a ticket in chronological order.
+// Internal notes are only included when includeInternal is set.
+func (db *MySQLDB) GetTicketComments(ticketID int64, includeInternal bool) ([]model.TicketComment, error) {
+	query := `
+	SELECT id, ticket_id, author_id, author_contact_id, body, internal, created_at
+	FROM ticket_comments
+	WHERE ticket_id = ? AND (internal = FALSE OR ?)
+	ORDER BY created_at, id`
+
+	rows, err := db.DB.Query(query, ticketID, includeInternal)
+	if err != nil {
+		log.Error("Failed to query ticket comments: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []model.TicketComment{}
+	for rows.Next() {
+		var c model.TicketComment
+		if err := rows.Scan(&c.ID, &c.TicketID, &c.AuthorID, &c.AuthorContactID, &c.Body, &c.Internal, &c.CreatedAt); err != nil {
+			log.Error("Failed to scan ticket comment row: ", err)
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+// GetTicketComment fetches a single comment
+func (db *MySQLDB) GetTicketComment(id int64) (*model.TicketComment, error) {
+	var c model.TicketComment
+	err := db.DB.QueryRow(`
+	SELECT id, ticket_id, author_id, author_contact_id, body, internal, created_at
+	FROM ticket_comments WHERE id = ?`, id).Scan(
+		&c.ID, &c.TicketID, &c.AuthorID, &c.AuthorContactID, &c.Body, &c.Internal, &c.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
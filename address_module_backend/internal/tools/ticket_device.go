@@ -0,0 +1,198 @@
+package tools
+
+import (
+	"address_module/internal/model"
+	"database/sql"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// TicketDeviceSnapshot captures the device fields stored alongside a ticket link
+func TicketDeviceSnapshot(d *DeviceParams) model.TicketDevice {
+	return model.TicketDevice{
+		DeviceID:     d.ID,
+		Name:         d.Name,
+		Hostname:     d.Hostname,
+		IP:           d.IP,
+		LocationText: d.LocationText,
+		Source:       "live",
+	}
+}
+
+func insertTicketDevices(tx *sql.Tx, ticketID int64, devices []model.TicketDevice) error {
+	query := `
+	INSERT INTO ticket_devices (ticket_id, device_id, name, hostname, ip, location_text)
+	VALUES (?, ?, ?, ?, ?, ?)`
+
+	for _, d := range devices {
+		_, err := tx.Exec(query, ticketID, d.DeviceID, d.Name, d.Hostname, d.IP, d.LocationText)
+		if err != nil {
+			log.Error("Failed to link device ", d.DeviceID, " to ticket: ", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// InsertTicketWithDevices inserts a ticket and links it to the given devices
+func (db *MySQLDB) InsertTicketWithDevices(ticket model.Ticket, devices []model.TicketDevice) (int64, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return 0, err
+	}
+
+	result, err := tx.Exec(ticketInsertQuery, ticketInsertArgs(ticket)...)
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to insert ticket: ", err)
+		return 0, err
+	}
+
+	ticketID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to get ticket ID: ", err)
+		return 0, err
+	}
+
+	if err := insertTicketDevices(tx, ticketID, devices); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return 0, err
+	}
+
+	log.WithFields(log.Fields{
+		"ticket_id": ticketID,
+		"devices":   len(devices),
+	}).Info("Ticket inserted and devices linked successfully")
+	return ticketID, nil
+}
+
+// UpdateTicketWithDevices updates the editable fields of a ticket and, if
+// devices is non-nil, replaces its device links in the same transaction. With
+// setDueDates the ticket's FirstResponseDueAt and ResolutionDueAt are written
+// as well, which resets the escalation like SetTicketDueDates.
+func (db *MySQLDB) UpdateTicketWithDevices(ticket model.Ticket, devices []model.TicketDevice, setDueDates bool) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return err
+	}
+
+	if _, err := tx.Exec(ticketUpdateQuery, ticketUpdateArgs(ticket)...); err != nil {
+		tx.Rollback()
+		log.Error("Failed to update ticket: ", err)
+		return err
+	}
+
+	if setDueDates {
+		if _, err := tx.Exec(ticketDueDatesQuery, ticket.FirstResponseDueAt, ticket.ResolutionDueAt, ticket.ID); err != nil {
+			tx.Rollback()
+			log.Error("Failed to set ticket due dates: ", err)
+			return err
+		}
+	}
+
+	if devices != nil {
+		if _, err := tx.Exec(`DELETE FROM ticket_devices WHERE ticket_id = ?`, ticket.ID); err != nil {
+			tx.Rollback()
+			log.Error("Failed to clear ticket devices: ", err)
+			return err
+		}
+		if err := insertTicketDevices(tx, ticket.ID, devices); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	log.Info("Ticket updated successfully")
+	return nil
+}
+
+// GetTicketDevices returns the stored device links of a ticket
+func (db *MySQLDB) GetTicketDevices(ticketID int64) ([]model.TicketDevice, error) {
+	rows, err := db.DB.Query(`
+	SELECT device_id, COALESCE(name, ''), COALESCE(hostname, ''), COALESCE(ip, ''),
+	       COALESCE(location_text, ''), device_deleted
+	FROM ticket_devices
+	WHERE ticket_id = ?
+	ORDER BY linked_at, device_id`, ticketID)
+	if err != nil {
+		log.Error("Failed to query ticket devices: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := []model.TicketDevice{}
+	for rows.Next() {
+		d := model.TicketDevice{Source: "snapshot"}
+		if err := rows.Scan(&d.DeviceID, &d.Name, &d.Hostname, &d.IP, &d.LocationText, &d.Deleted); err != nil {
+			return nil, err
+		}
+		devices = append(devices, d)
+	}
+	return devices, rows.Err()
+}
+
+// GetTicketsByDeviceID returns every ticket linked to a device, newest first
+func (db *MySQLDB) GetTicketsByDeviceID(deviceID int64) ([]model.Ticket, error) {
+	query := `
+	SELECT ` + prefixColumns("t", ticketColumns) + `
+	FROM tickets t
+	JOIN ticket_devices td ON td.ticket_id = t.id
+	WHERE td.device_id = ?
+	ORDER BY t.id DESC`
+
+	rows, err := db.DB.Query(query, deviceID)
+	if err != nil {
+		log.Error("Failed to query tickets by device ID: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tickets := []model.Ticket{}
+	for rows.Next() {
+		ticket, err := scanTicket(rows)
+		if err != nil {
+			log.Error("Failed to scan ticket row: ", err)
+			return nil, err
+		}
+		tickets = append(tickets, *ticket)
+	}
+	return tickets, rows.Err()
+}
+
+// MarkDeviceDeleted flags all links to a device as deleted and refreshes their
+// snapshot, so tickets keep showing what the device was after it is gone.
+func (db *MySQLDB) MarkDeviceDeleted(snapshot model.TicketDevice) error {
+	_, err := db.DB.Exec(`
+	UPDATE ticket_devices
+	SET device_deleted = TRUE, name = ?, hostname = ?, ip = ?, location_text = ?
+	WHERE device_id = ?`,
+		snapshot.Name, snapshot.Hostname, snapshot.IP, snapshot.LocationText, snapshot.DeviceID)
+	if err != nil {
+		log.Error("Failed to mark device links as deleted: ", err)
+		return err
+	}
+	return nil
+}
+
+// UnmarkDeviceDeleted reverts MarkDeviceDeleted when the device deletion did not go through
+func (db *MySQLDB) UnmarkDeviceDeleted(deviceID int64) error {
+	_, err := db.DB.Exec(`UPDATE ticket_devices SET device_deleted = FALSE WHERE device_id = ?`, deviceID)
+	if err != nil {
+		log.Error("Failed to unmark device links: ", err)
+		return err
+	}
+	return nil
+}
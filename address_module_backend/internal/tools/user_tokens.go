@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// Purposes of single-use user tokens
+const (
+	UserTokenPasswordReset     = "password_reset"
+	UserTokenEmailVerification = "email_verification"
+)
+
+// ErrUserTokenInvalid is returned for unknown, used or expired tokens
+var ErrUserTokenInvalid = errors.New("token invalid or expired")
+
+// CreateUserToken stores a new token for a user. Earlier unused tokens with
+// the same purpose stop working, so only the latest link in a mailbox is valid.
+func (db *MySQLDB) CreateUserToken(userID int64, purpose, tokenHash string, expiresAt time.Time) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE user_tokens SET used_at = ? WHERE user_id = ? AND purpose = ? AND used_at IS NULL`,
+		time.Now(), userID, purpose); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at) VALUES (?, ?, ?, ?)`,
+		userID, purpose, tokenHash, expiresAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// consumeUserToken marks a token as used and runs apply for its user in the
+// same transaction, so the token stays valid if apply fails. Each token works
+// exactly once and only before it expires.
+func (db *MySQLDB) consumeUserToken(purpose, tokenHash string, now time.Time, apply func(tx *sql.Tx, userID int64) error) (int64, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var (
+		id, userID int64
+		expiresAt  time.Time
+		usedAt     *time.Time
+	)
+	err = tx.QueryRow(`SELECT id, user_id, expires_at, used_at FROM user_tokens WHERE token_hash = ? AND purpose = ? FOR UPDATE`,
+		tokenHash, purpose).Scan(&id, &userID, &expiresAt, &usedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrUserTokenInvalid
+	}
+	if err != nil {
+		return 0, err
+	}
+	if usedAt != nil || !now.Before(expiresAt) {
+		return 0, ErrUserTokenInvalid
+	}
+
+	if _, err := tx.Exec(`UPDATE user_tokens SET used_at = ? WHERE id = ?`, now, id); err != nil {
+		return 0, err
+	}
+	if err := apply(tx, userID); err != nil {
+		return 0, err
+	}
+	return userID, tx.Commit()
+}
+
+// ResetPassword uses up a password reset token and sets the new password hash.
+// Following the link proves the user owns the address, so the email is marked
+// verified as well. Tokens of deleted users are rejected as invalid.
+func (db *MySQLDB) ResetPassword(tokenHash, hashedPassword string, now time.Time) (int64, error) {
+	return db.consumeUserToken(UserTokenPasswordReset, tokenHash, now, func(tx *sql.Tx, userID int64) error {
+		res, err := tx.Exec(`UPDATE users SET hashed_password = ? WHERE id = ? AND deleted_at IS NULL`, hashedPassword, userID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrUserTokenInvalid
+		}
+		_, err = tx.Exec(markEmailVerifiedQuery, now, userID)
+		return err
+	})
+}
+
+// VerifyEmail uses up an email verification token and marks the user's address verified
+func (db *MySQLDB) VerifyEmail(tokenHash string, now time.Time) (int64, error) {
+	return db.consumeUserToken(UserTokenEmailVerification, tokenHash, now, func(tx *sql.Tx, userID int64) error {
+		_, err := tx.Exec(markEmailVerifiedQuery, now, userID)
+		return err
+	})
+}
+
+const markEmailVerifiedQuery = `UPDATE users SET email_verified_at = ? WHERE id = ? AND email_verified_at IS NULL`
+
+// MarkEmailVerified records that the user proved they own their email address
+func (db *MySQLDB) MarkEmailVerified(userID int64, at time.Time) error {
+	_, err := db.DB.Exec(markEmailVerifiedQuery, at, userID)
+	return err
+}
@@ -0,0 +1,474 @@
+package tools
+
+import (
+	"address_module/internal/model"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrDefaultWorkflow is returned when trying to delete the default workflow
+	ErrDefaultWorkflow = errors.New("the default workflow cannot be deleted")
+	// ErrNoDefaultWorkflow is returned when an update would leave no default workflow
+	ErrNoDefaultWorkflow = errors.New("another workflow must be made the default first")
+	// ErrWorkflowStatusesInUse is returned when deleting a workflow would move
+	// tickets to the default workflow in a status it does not know
+	ErrWorkflowStatusesInUse = errors.New("tickets of this workflow are in statuses the default workflow does not have")
+)
+
+// TransitionError is returned by TransitionTicket when the workflow forbids a move
+type TransitionError struct {
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *TransitionError) Error() string {
+	if len(e.Allowed) == 0 {
+		return fmt.Sprintf("transition from '%s' to '%s' is not allowed; '%s' is a final status", e.From, e.To, e.From)
+	}
+	return fmt.Sprintf("transition from '%s' to '%s' is not allowed; allowed: %s", e.From, e.To, strings.Join(e.Allowed, ", "))
+}
+
+// queryer is implemented by *sql.DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func loadWorkflowTransitions(q queryer, wf *model.Workflow) error {
+	rows, err := q.Query(`
+	SELECT id, from_status, to_status, COALESCE(name, '')
+	FROM workflow_transitions
+	WHERE workflow_id = ?
+	ORDER BY id`, wf.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	wf.Transitions = []model.WorkflowTransition{}
+	for rows.Next() {
+		var t model.WorkflowTransition
+		if err := rows.Scan(&t.ID, &t.FromStatus, &t.ToStatus, &t.Name); err != nil {
+			return err
+		}
+		wf.Transitions = append(wf.Transitions, t)
+	}
+	return rows.Err()
+}
+
+func loadWorkflowClosedStatuses(q queryer, wf *model.Workflow) error {
+	rows, err := q.Query(`SELECT status FROM workflow_closed_statuses WHERE workflow_id = ? ORDER BY status`, wf.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	wf.ClosedStatuses = []string{}
+	for rows.Next() {
+		var status string
+		if err := rows.Scan(&status); err != nil {
+			return err
+		}
+		wf.ClosedStatuses = append(wf.ClosedStatuses, status)
+	}
+	return rows.Err()
+}
+
+func loadWorkflow(q queryer, where string, args ...interface{}) (*model.Workflow, error) {
+	var wf model.Workflow
+	err := q.QueryRow(`
+	SELECT id, name, COALESCE(description, ''), initial_status, is_default
+	FROM workflows `+where, args...).Scan(&wf.ID, &wf.Name, &wf.Description, &wf.InitialStatus, &wf.IsDefault)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadWorkflowTransitions(q, &wf); err != nil {
+		return nil, err
+	}
+	if err := loadWorkflowClosedStatuses(q, &wf); err != nil {
+		return nil, err
+	}
+	return &wf, nil
+}
+
+func insertWorkflowTransitions(tx *sql.Tx, workflowID int64, transitions []model.WorkflowTransition) error {
+	for _, t := range transitions {
+		_, err := tx.Exec(`
+		INSERT INTO workflow_transitions (workflow_id, from_status, to_status, name)
+		VALUES (?, ?, ?, ?)`, workflowID, t.FromStatus, t.ToStatus, t.Name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertWorkflowClosedStatuses(tx *sql.Tx, workflowID int64, statuses []string) error {
+	for _, status := range statuses {
+		if _, err := tx.Exec(`INSERT INTO workflow_closed_statuses (workflow_id, status) VALUES (?, ?)`, workflowID, status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InsertWorkflow inserts a workflow with its transitions and returns its ID
+func (db *MySQLDB) InsertWorkflow(wf model.Workflow) (int64, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return 0, err
+	}
+
+	if wf.IsDefault {
+		if _, err := tx.Exec(`UPDATE workflows SET is_default = FALSE`); err != nil {
+			tx.Rollback()
+			log.Error("Failed to clear default workflow: ", err)
+			return 0, err
+		}
+	}
+
+	result, err := tx.Exec(`
+	INSERT INTO workflows (name, description, initial_status, is_default)
+	VALUES (?, ?, ?, ?)`, wf.Name, wf.Description, wf.InitialStatus, wf.IsDefault)
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to insert workflow: ", err)
+		return 0, err
+	}
+
+	workflowID, err := result.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to get workflow ID: ", err)
+		return 0, err
+	}
+
+	if err := insertWorkflowTransitions(tx, workflowID, wf.Transitions); err != nil {
+		tx.Rollback()
+		log.Error("Failed to insert workflow transitions: ", err)
+		return 0, err
+	}
+	if err := insertWorkflowClosedStatuses(tx, workflowID, wf.ClosedStatuses); err != nil {
+		tx.Rollback()
+		log.Error("Failed to insert workflow closed statuses: ", err)
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return 0, err
+	}
+
+	log.Info("Workflow inserted successfully with ID: ", workflowID)
+	return workflowID, nil
+}
+
+// GetWorkflowByID fetches a workflow and its transitions
+func (db *MySQLDB) GetWorkflowByID(id int64) (*model.Workflow, error) {
+	wf, err := loadWorkflow(db.DB, `WHERE id = ?`, id)
+	if err != nil {
+		log.Error("Failed to get workflow: ", err)
+		return nil, err
+	}
+	return wf, nil
+}
+
+// GetWorkflowByName fetches a workflow by its unique name
+func (db *MySQLDB) GetWorkflowByName(name string) (*model.Workflow, error) {
+	return loadWorkflow(db.DB, `WHERE name = ?`, name)
+}
+
+// GetDefaultWorkflow fetches the workflow used for tickets without an explicit workflow
+func (db *MySQLDB) GetDefaultWorkflow() (*model.Workflow, error) {
+	wf, err := loadWorkflow(db.DB, `WHERE is_default = TRUE ORDER BY id LIMIT 1`)
+	if err != nil {
+		log.Error("Failed to get default workflow: ", err)
+		return nil, err
+	}
+	return wf, nil
+}
+
+// GetAllWorkflows retrieves all workflows with their transitions
+func (db *MySQLDB) GetAllWorkflows() ([]model.Workflow, error) {
+	rows, err := db.DB.Query(`SELECT id FROM workflows ORDER BY id`)
+	if err != nil {
+		log.Error("Failed to query workflows: ", err)
+		return nil, err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	workflows := make([]model.Workflow, 0, len(ids))
+	for _, id := range ids {
+		wf, err := db.GetWorkflowByID(id)
+		if err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, *wf)
+	}
+	return workflows, nil
+}
+
+// UpdateWorkflow replaces a workflow definition including all of its transitions
+// and closed statuses
+func (db *MySQLDB) UpdateWorkflow(wf model.Workflow) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return err
+	}
+
+	if wf.IsDefault {
+		if _, err := tx.Exec(`UPDATE workflows SET is_default = FALSE WHERE id <> ?`, wf.ID); err != nil {
+			tx.Rollback()
+			log.Error("Failed to clear default workflow: ", err)
+			return err
+		}
+	} else {
+		// New tickets need a default workflow, so the default can only move, never vanish
+		var otherDefaults int
+		err := tx.QueryRow(`SELECT COUNT(*) FROM workflows WHERE is_default = TRUE AND id <> ? FOR UPDATE`, wf.ID).Scan(&otherDefaults)
+		if err != nil {
+			tx.Rollback()
+			log.Error("Failed to check default workflow: ", err)
+			return err
+		}
+		if otherDefaults == 0 {
+			var isDefault bool
+			err := tx.QueryRow(`SELECT is_default FROM workflows WHERE id = ?`, wf.ID).Scan(&isDefault)
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+			if isDefault {
+				tx.Rollback()
+				return ErrNoDefaultWorkflow
+			}
+		}
+	}
+
+	result, err := tx.Exec(`
+	UPDATE workflows SET name = ?, description = ?, initial_status = ?, is_default = ?
+	WHERE id = ?`, wf.Name, wf.Description, wf.InitialStatus, wf.IsDefault, wf.ID)
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to update workflow: ", err)
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		var exists int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM workflows WHERE id = ?`, wf.ID).Scan(&exists); err != nil || exists == 0 {
+			tx.Rollback()
+			return sql.ErrNoRows
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM workflow_transitions WHERE workflow_id = ?`, wf.ID); err != nil {
+		tx.Rollback()
+		log.Error("Failed to clear workflow transitions: ", err)
+		return err
+	}
+	if err := insertWorkflowTransitions(tx, wf.ID, wf.Transitions); err != nil {
+		tx.Rollback()
+		log.Error("Failed to insert workflow transitions: ", err)
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM workflow_closed_statuses WHERE workflow_id = ?`, wf.ID); err != nil {
+		tx.Rollback()
+		log.Error("Failed to clear workflow closed statuses: ", err)
+		return err
+	}
+	if err := insertWorkflowClosedStatuses(tx, wf.ID, wf.ClosedStatuses); err != nil {
+		tx.Rollback()
+		log.Error("Failed to insert workflow closed statuses: ", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return err
+	}
+
+	log.Info("Workflow updated successfully")
+	return nil
+}
+
+// DeleteWorkflow deletes a workflow. Tickets using it fall back to the default
+// workflow, so the delete is refused while any of them is in a status the
+// default workflow does not have.
+func (db *MySQLDB) DeleteWorkflow(id int64) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	var isDefault bool
+	err = tx.QueryRow(`SELECT is_default FROM workflows WHERE id = ? FOR UPDATE`, id).Scan(&isDefault)
+	if err != nil {
+		log.Error("Failed to get workflow: ", err)
+		return err
+	}
+	if isDefault {
+		return ErrDefaultWorkflow
+	}
+
+	fallback, err := loadWorkflow(tx, `WHERE is_default = TRUE ORDER BY id LIMIT 1`)
+	if err != nil {
+		log.Error("Failed to load default workflow: ", err)
+		return err
+	}
+	rows, err := tx.Query(`SELECT DISTINCT status FROM tickets WHERE workflow_id = ? FOR UPDATE`, id)
+	if err != nil {
+		log.Error("Failed to query ticket statuses: ", err)
+		return err
+	}
+	var stranded []string
+	for rows.Next() {
+		var status string
+		if err := rows.Scan(&status); err != nil {
+			rows.Close()
+			return err
+		}
+		if !fallback.HasStatus(status) {
+			stranded = append(stranded, status)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(stranded) > 0 {
+		return fmt.Errorf("%w: %s", ErrWorkflowStatusesInUse, strings.Join(stranded, ", "))
+	}
+
+	if _, err := tx.Exec(`DELETE FROM workflows WHERE id = ?`, id); err != nil {
+		log.Error("Failed to delete workflow: ", err)
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return err
+	}
+	log.Info("Workflow deleted successfully")
+	return nil
+}
+
+// TransitionTicket moves a ticket to a new status if its workflow allows it
+// and records who made the change. Illegal moves return a *TransitionError.
+func (db *MySQLDB) TransitionTicket(ticketID int64, toStatus string, userID *int64, comment string) (*model.TicketTransition, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		log.Error("Failed to begin transaction: ", err)
+		return nil, err
+	}
+
+	var fromStatus string
+	var workflowID sql.NullInt64
+	err = tx.QueryRow(`SELECT status, workflow_id FROM tickets WHERE id = ? FOR UPDATE`, ticketID).Scan(&fromStatus, &workflowID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	var wf *model.Workflow
+	if workflowID.Valid {
+		wf, err = loadWorkflow(tx, `WHERE id = ?`, workflowID.Int64)
+	} else {
+		wf, err = loadWorkflow(tx, `WHERE is_default = TRUE ORDER BY id LIMIT 1`)
+	}
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to load workflow for ticket: ", err)
+		return nil, err
+	}
+
+	if !wf.CanTransition(fromStatus, toStatus) {
+		tx.Rollback()
+		return nil, &TransitionError{From: fromStatus, To: toStatus, Allowed: wf.NextStatuses(fromStatus)}
+	}
+
+	if _, err := tx.Exec(`UPDATE tickets SET status = ? WHERE id = ?`, toStatus, ticketID); err != nil {
+		tx.Rollback()
+		log.Error("Failed to update ticket status: ", err)
+		return nil, err
+	}
+
+	result, err := tx.Exec(`
+	INSERT INTO ticket_status_history (ticket_id, from_status, to_status, changed_by, comment)
+	VALUES (?, ?, ?, ?, ?)`, ticketID, fromStatus, toStatus, userID, comment)
+	if err != nil {
+		tx.Rollback()
+		log.Error("Failed to record ticket transition: ", err)
+		return nil, err
+	}
+	historyID, _ := result.LastInsertId()
+
+	var transition model.TicketTransition
+	err = tx.QueryRow(`
+	SELECT id, ticket_id, from_status, to_status, changed_by, COALESCE(comment, ''), changed_at
+	FROM ticket_status_history WHERE id = ?`, historyID).Scan(
+		&transition.ID, &transition.TicketID, &transition.FromStatus, &transition.ToStatus,
+		&transition.ChangedBy, &transition.Comment, &transition.ChangedAt,
+	)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Error("Failed to commit transaction: ", err)
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{
+		"ticket_id": ticketID,
+		"from":      fromStatus,
+		"to":        toStatus,
+	}).Info("Ticket status changed")
+	return &transition, nil
+}
+
+// GetTicketTransitions returns the status history of a ticket, oldest first
+func (db *MySQLDB) GetTicketTransitions(ticketID int64) ([]model.TicketTransition, error) {
+	rows, err := db.DB.Query(`
+	SELECT id, ticket_id, from_status, to_status, changed_by, COALESCE(comment, ''), changed_at
+	FROM ticket_status_history
+	WHERE ticket_id = ?
+	ORDER BY changed_at, id`, ticketID)
+	if err != nil {
+		log.Error("Failed to query ticket transitions: ", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	transitions := []model.TicketTransition{}
+	for rows.Next() {
+		var t model.TicketTransition
+		if err := rows.Scan(&t.ID, &t.TicketID, &t.FromStatus, &t.ToStatus, &t.ChangedBy, &t.Comment, &t.ChangedAt); err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, t)
+	}
+	return transitions, rows.Err()
+}